@@ -0,0 +1,381 @@
+package helper
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ApprovalRequest is a request for the decision of an operator.
+type ApprovalRequest struct {
+	// Message is shown to the operator.
+	Message string
+	// Choices are the answers the operator can select.
+	// The operator can type any answer if it is empty.
+	Choices []string
+	// Screenshot returns a PNG screenshot of the current page. It can be nil.
+	Screenshot func() ([]byte, error)
+}
+
+// ApprovalProvider asks an operator for approval.
+type ApprovalProvider interface {
+	// Approve waits for the decision of the operator and returns the answer.
+	// It returns ErrCanceledByUser if the operator rejects the request.
+	Approve(ctx context.Context, req *ApprovalRequest) (string, error)
+}
+
+// ApprovalProviderFunc is an adapter to allow the use of ordinary functions as ApprovalProvider.
+type ApprovalProviderFunc func(ctx context.Context, req *ApprovalRequest) (string, error)
+
+// Approve calls f(ctx, req).
+func (f ApprovalProviderFunc) Approve(ctx context.Context, req *ApprovalRequest) (string, error) {
+	return f(ctx, req)
+}
+
+// TerminalApproval returns an ApprovalProvider which asks the operator on the terminal.
+//
+// The answer is compared with Choices case-insensitively. The screenshot is not shown.
+func TerminalApproval(r io.Reader) ApprovalProvider {
+	return ApprovalProviderFunc(func(ctx context.Context, req *ApprovalRequest) (string, error) {
+		var answer string
+		p := &Prompt{Message: req.Message, Choices: req.Choices, IgnoreCase: true}
+		if err := Ask(r, p, &answer).Do(ctx); err != nil {
+			return "", err
+		}
+		return answer, nil
+	})
+}
+
+// WaitApproval is an action that asks provider for approval with a screenshot of the current page
+// and sets the answer to answer.
+//
+// It returns ErrCanceledByUser if the operator rejects it.
+func WaitApproval(provider ApprovalProvider, message string, answer *string, choices ...string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		req := &ApprovalRequest{
+			Message: message,
+			Choices: choices,
+			Screenshot: func() ([]byte, error) {
+				return page.CaptureScreenshot().Do(ctx)
+			},
+		}
+		log.Printf("WaitApproval: message=%q\n", message)
+		res, err := provider.Approve(ctx, req)
+		if err != nil {
+			log.Printf("WaitApproval: error=%v\n", err)
+			return err
+		}
+		log.Println("WaitApproval: Approved")
+		if answer != nil {
+			*answer = res
+		}
+		return nil
+	})
+}
+
+type approvalResult struct {
+	answer string
+	err    error
+}
+
+type pendingApproval struct {
+	id  int64
+	req *ApprovalRequest
+	ch  chan approvalResult
+}
+
+// ApprovalServer is an ApprovalProvider which shows pending requests on a web page.
+//
+// The page shows the message and a live screenshot of each request, and buttons to approve or reject it.
+// Every request must have the random token of the server in the token parameter or the X-Approval-Token header,
+// because the screenshots may show logged in pages. The page and its forms carry the token.
+//
+// It implements http.Handler, so it can also be mounted on an existing server
+// with a path ending with a slash, e.g. http.StripPrefix("/approval", s) for "/approval/".
+type ApprovalServer struct {
+	token string
+
+	mu      sync.Mutex
+	nextID  int64
+	pending []*pendingApproval
+
+	srv *http.Server
+	url string
+}
+
+var _ ApprovalProvider = (*ApprovalServer)(nil)
+var _ http.Handler = (*ApprovalServer)(nil)
+
+// NewApprovalServer returns a new ApprovalServer which is not listening.
+func NewApprovalServer() *ApprovalServer {
+	token, err := randomToken()
+	if err != nil {
+		panic(err)
+	}
+	return &ApprovalServer{token: token}
+}
+
+// Token returns the token required by the server.
+func (s *ApprovalServer) Token() string {
+	return s.token
+}
+
+// StartApprovalServer starts a new ApprovalServer listening on addr, such as "127.0.0.1:8080".
+func StartApprovalServer(addr string) (*ApprovalServer, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := NewApprovalServer()
+	s.srv = &http.Server{Handler: s}
+	s.url = "http://" + l.Addr().String()
+	go func() {
+		if err := s.srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Printf("ApprovalServer: error=%v\n", err)
+		}
+	}()
+	log.Printf("ApprovalServer: url=%s/?token=%s\n", s.url, s.token)
+	return s, nil
+}
+
+// URL returns the url of the server started by StartApprovalServer.
+// The page is at URL()+"/?token="+Token().
+func (s *ApprovalServer) URL() string {
+	return s.url
+}
+
+// Close stops the server started by StartApprovalServer.
+//
+// Pending requests are not resolved.
+func (s *ApprovalServer) Close() error {
+	if s.srv == nil {
+		return nil
+	}
+	return s.srv.Close()
+}
+
+// Approve implements ApprovalProvider.
+func (s *ApprovalServer) Approve(ctx context.Context, req *ApprovalRequest) (string, error) {
+	s.mu.Lock()
+	s.nextID++
+	p := &pendingApproval{id: s.nextID, req: req, ch: make(chan approvalResult, 1)}
+	s.pending = append(s.pending, p)
+	s.mu.Unlock()
+	defer s.remove(p.id)
+
+	log.Printf("ApprovalServer: waiting id=%d message=%q\n", p.id, req.Message)
+	select {
+	case res := <-p.ch:
+		return res.answer, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (s *ApprovalServer) remove(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, p := range s.pending {
+		if p.id == id {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *ApprovalServer) get(id int64) *pendingApproval {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.pending {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of pending requests.
+func (s *ApprovalServer) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *ApprovalServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Approval-Token")
+	if token == "" {
+		token = r.FormValue("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/":
+		s.serveIndex(w, r)
+	case strings.HasPrefix(r.URL.Path, "/screenshot/"):
+		s.serveScreenshot(w, r, strings.TrimPrefix(r.URL.Path, "/screenshot/"))
+	case strings.HasPrefix(r.URL.Path, "/respond/"):
+		s.serveRespond(w, r, strings.TrimPrefix(r.URL.Path, "/respond/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *ApprovalServer) lookup(w http.ResponseWriter, r *http.Request, idstr string) *pendingApproval {
+	id, err := strconv.ParseInt(idstr, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return nil
+	}
+	p := s.get(id)
+	if p == nil {
+		http.Error(w, "request is already resolved", http.StatusGone)
+		return nil
+	}
+	return p
+}
+
+func (s *ApprovalServer) serveIndex(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	pending := make([]*pendingApproval, len(s.pending))
+	copy(pending, s.pending)
+	s.mu.Unlock()
+	type item struct {
+		ID         int64
+		Message    string
+		Choices    []string
+		Screenshot bool
+	}
+	data := struct {
+		Token string
+		Items []item
+	}{Token: s.token}
+	for _, p := range pending {
+		data.Items = append(data.Items, item{ID: p.id, Message: p.req.Message, Choices: p.req.Choices, Screenshot: p.req.Screenshot != nil})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := approvalTemplate.Execute(w, data); err != nil {
+		log.Printf("ApprovalServer: error=%v\n", err)
+	}
+}
+
+func (s *ApprovalServer) serveScreenshot(w http.ResponseWriter, r *http.Request, idstr string) {
+	p := s.lookup(w, r, idstr)
+	if p == nil {
+		return
+	}
+	if p.req.Screenshot == nil {
+		http.NotFound(w, r)
+		return
+	}
+	b, err := p.req.Screenshot()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(b)
+}
+
+func (s *ApprovalServer) serveRespond(w http.ResponseWriter, r *http.Request, idstr string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	p := s.lookup(w, r, idstr)
+	if p == nil {
+		return
+	}
+	var res approvalResult
+	switch r.PostFormValue("action") {
+	case "approve":
+		res.answer = r.PostFormValue("value")
+		if len(p.req.Choices) > 0 && !containsString(p.req.Choices, res.answer) {
+			http.Error(w, fmt.Sprintf("invalid choice %q", res.answer), http.StatusBadRequest)
+			return
+		}
+		log.Printf("ApprovalServer: approved id=%d\n", p.id)
+	case "reject":
+		res.err = ErrCanceledByUser
+		log.Printf("ApprovalServer: rejected id=%d\n", p.id)
+	default:
+		http.Error(w, "invalid action", http.StatusBadRequest)
+		return
+	}
+	select {
+	case p.ch <- res:
+	default:
+		http.Error(w, "request is already resolved", http.StatusGone)
+		return
+	}
+	// the relative location works under any prefix, while http.Redirect resolves it by the stripped path
+	w.Header().Set("Location", "../?token="+url.QueryEscape(s.token))
+	w.WriteHeader(http.StatusSeeOther)
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var approvalTemplate = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>Approval requests</title>
+<script>
+setInterval(function() {
+	document.querySelectorAll("img[data-src]").forEach(function(img) {
+		img.src = img.dataset.src + "&t=" + Date.now();
+	});
+}, 2000);
+</script>
+</head>
+<body>
+{{$token := .Token}}
+{{range .Items}}
+<section>
+	<p>{{.Message}}</p>
+	{{if .Screenshot}}<img data-src="screenshot/{{.ID}}?token={{$token}}" src="screenshot/{{.ID}}?token={{$token}}" style="max-width: 100%;">{{end}}
+	<form method="post" action="respond/{{.ID}}">
+		<input type="hidden" name="token" value="{{$token}}">
+		{{if .Choices}}
+		{{range .Choices}}<button type="submit" name="value" value="{{.}}">{{.}}</button>{{end}}
+		<input type="hidden" name="action" value="approve">
+		{{else}}
+		<input type="text" name="value">
+		<button type="submit" name="action" value="approve">Approve</button>
+		{{end}}
+	</form>
+	<form method="post" action="respond/{{.ID}}">
+		<input type="hidden" name="token" value="{{$token}}">
+		<button type="submit" name="action" value="reject">Reject</button>
+	</form>
+</section>
+{{else}}
+<p>No pending requests.</p>
+<script>setTimeout(function() { location.reload(); }, 2000);</script>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,242 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func waitPending(t *testing.T, s *ApprovalServer, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending requests: %d != %d", s.Pending(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestApprovalServer(t *testing.T) {
+	t.Parallel()
+	s, err := StartApprovalServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	respond := func(id string, form url.Values) int {
+		t.Helper()
+		form.Set("token", s.Token())
+		res, err := client.PostForm(s.URL()+"/respond/"+id, form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	tests := []struct {
+		name       string
+		req        *ApprovalRequest
+		form       url.Values
+		wantStatus int
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "approve with value",
+			req:        &ApprovalRequest{Message: "Enter code", Screenshot: func() ([]byte, error) { return []byte("png"), nil }},
+			form:       url.Values{"action": {"approve"}, "value": {"123456"}},
+			wantStatus: http.StatusSeeOther,
+			want:       "123456",
+		},
+		{
+			name:       "approve choice",
+			req:        &ApprovalRequest{Message: "Which account?", Choices: []string{"alice", "bob"}},
+			form:       url.Values{"action": {"approve"}, "value": {"bob"}},
+			wantStatus: http.StatusSeeOther,
+			want:       "bob",
+		},
+		{
+			name:       "reject",
+			req:        &ApprovalRequest{Message: "Purchase?"},
+			form:       url.Values{"action": {"reject"}},
+			wantStatus: http.StatusSeeOther,
+			wantErr:    ErrCanceledByUser,
+		},
+	}
+	for i, tt := range tests {
+		id := strconv.Itoa(i + 1)
+		type result struct {
+			answer string
+			err    error
+		}
+		ch := make(chan result, 1)
+		go func() {
+			answer, err := s.Approve(context.Background(), tt.req)
+			ch <- result{answer, err}
+		}()
+		waitPending(t, s, 1)
+
+		res, err := http.Get(s.URL() + "/?token=" + s.Token())
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if !strings.Contains(string(body), tt.req.Message) {
+			t.Fatalf("%s: message is not shown:\n%s", tt.name, body)
+		}
+		if tt.req.Screenshot != nil {
+			res, err := http.Get(s.URL() + "/screenshot/" + id + "?token=" + s.Token())
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if string(b) != "png" || res.Header.Get("Content-Type") != "image/png" {
+				t.Fatalf("%s: invalid screenshot: %q", tt.name, b)
+			}
+		}
+		if len(tt.req.Choices) > 0 {
+			if status := respond(id, url.Values{"action": {"approve"}, "value": {"carol"}}); status != http.StatusBadRequest {
+				t.Fatalf("%s: invalid choice must be rejected: %d", tt.name, status)
+			}
+		}
+		if status := respond(id, tt.form); status != tt.wantStatus {
+			t.Fatalf("%s: %#v != %#v", tt.name, status, tt.wantStatus)
+		}
+		got := <-ch
+		if !errors.Is(got.err, tt.wantErr) {
+			t.Fatalf("%s: %#v != %#v", tt.name, got.err, tt.wantErr)
+		}
+		if got.answer != tt.want {
+			t.Fatalf("%s: %#v != %#v", tt.name, got.answer, tt.want)
+		}
+		waitPending(t, s, 0)
+		if status := respond(id, tt.form); status != http.StatusGone {
+			t.Fatalf("%s: resolved request must be gone: %d", tt.name, status)
+		}
+	}
+}
+
+func TestApprovalServerToken(t *testing.T) {
+	t.Parallel()
+	s := NewApprovalServer()
+	if len(s.Token()) < 32 || s.Token() == NewApprovalServer().Token() {
+		t.Fatalf("invalid token: %#v", s.Token())
+	}
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{name: "no token", req: httptest.NewRequest(http.MethodGet, "/", nil), status: http.StatusUnauthorized},
+		{name: "wrong token", req: httptest.NewRequest(http.MethodGet, "/?token=wrong", nil), status: http.StatusUnauthorized},
+		{name: "respond without token", req: httptest.NewRequest(http.MethodPost, "/respond/1", strings.NewReader("action=approve")), status: http.StatusUnauthorized},
+		{name: "query token", req: httptest.NewRequest(http.MethodGet, "/?token="+s.Token(), nil), status: http.StatusOK},
+		{name: "header token", req: func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-Approval-Token", s.Token())
+			return r
+		}(), status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, tt.req)
+			if w.Code != tt.status {
+				t.Fatalf("%#v != %#v", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestApprovalServerPrefix(t *testing.T) {
+	t.Parallel()
+	s := NewApprovalServer()
+	mux := http.NewServeMux()
+	mux.Handle("/approval/", http.StripPrefix("/approval", s))
+	ch := make(chan string, 1)
+	go func() {
+		answer, _ := s.Approve(context.Background(), &ApprovalRequest{Message: "test", Screenshot: func() ([]byte, error) { return nil, nil }})
+		ch <- answer
+	}()
+	waitPending(t, s, 1)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/approval/?token="+s.Token(), nil))
+	body := w.Body.String()
+	if strings.Contains(body, `"/screenshot/`) || strings.Contains(body, `"/respond/`) || !strings.Contains(body, `action="respond/1"`) {
+		t.Fatalf("links must be relative:\n%s", body)
+	}
+
+	form := url.Values{"token": {s.Token()}, "action": {"approve"}, "value": {"ok"}}
+	req := httptest.NewRequest(http.MethodPost, "/approval/respond/1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("%#v != %#v", w.Code, http.StatusSeeOther)
+	}
+	loc, err := req.URL.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Path != "/approval/" {
+		t.Fatalf("%#v != %#v", loc.Path, "/approval/")
+	}
+	if got := <-ch; got != "ok" {
+		t.Fatalf("%#v != %#v", got, "ok")
+	}
+}
+
+func TestApprovalServerCanceled(t *testing.T) {
+	t.Parallel()
+	s := NewApprovalServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := s.Approve(ctx, &ApprovalRequest{Message: "test"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("%#v != %#v", err, context.Canceled)
+	}
+	if s.Pending() != 0 {
+		t.Fatalf("canceled request is pending")
+	}
+}
+
+func TestWaitApproval(t *testing.T) {
+	t.Parallel()
+	ctx := WithPromptWriter(context.Background(), ioutil.Discard)
+	var got string
+	provider := TerminalApproval(bytes.NewBufferString("YES\n"))
+	if err := WaitApproval(provider, "continue?", &got, "yes", "no").Do(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got != "yes" {
+		t.Fatalf("%#v != %#v", got, "yes")
+	}
+
+	var req *ApprovalRequest
+	provider = ApprovalProviderFunc(func(ctx context.Context, r *ApprovalRequest) (string, error) {
+		req = r
+		return "", ErrCanceledByUser
+	})
+	if err := WaitApproval(provider, "purchase?", &got).Do(ctx); err != ErrCanceledByUser {
+		t.Fatalf("%#v != %#v", err, ErrCanceledByUser)
+	}
+	if req.Message != "purchase?" || req.Screenshot == nil {
+		t.Fatalf("invalid request: %+v", req)
+	}
+}
@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// ResourceBlocker is a RouteHandler which aborts requests by resource type or url.
+type ResourceBlocker struct {
+	// Types are the resource types to block.
+	Types []network.ResourceType
+	// URLs are the matchers of urls to block.
+	URLs []URLMatcher
+	// Next handles the requests which are not blocked.
+	// They are continued unmodified if Next is nil.
+	Next RouteHandler
+
+	blocked int64
+}
+
+// Blocks reports whether a request of typ to urlstr is blocked.
+func (b *ResourceBlocker) Blocks(typ network.ResourceType, urlstr string) bool {
+	for _, t := range b.Types {
+		if t == typ {
+			return true
+		}
+	}
+	for _, m := range b.URLs {
+		if m(urlstr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocked returns the number of blocked requests.
+func (b *ResourceBlocker) Blocked() int64 {
+	return atomic.LoadInt64(&b.blocked)
+}
+
+// ServeRequest implements RouteHandler.
+func (b *ResourceBlocker) ServeRequest(req *InterceptedRequest) error {
+	if b.Blocks(req.ResourceType, req.Request.URL) {
+		atomic.AddInt64(&b.blocked, 1)
+		return req.Abort(network.ErrorReasonBlockedByClient)
+	}
+	if b.Next != nil {
+		return b.Next.ServeRequest(req)
+	}
+	return req.Continue()
+}
+
+// BlockResources is an action that blocks requests matched by b.
+//
+// It runs InterceptRequests, so set the other handler to b.Next to combine them, e.g. NewHARReplayer.
+// The blocked requests other than documents are ignored by WaitResponse.
+func BlockResources(b *ResourceBlocker) chromedp.Action {
+	return InterceptRequests(b)
+}
+
+// isBlocked reports whether the failed request is blocked by the client.
+func isBlocked(e *network.EventLoadingFailed) bool {
+	return e.BlockedReason != "" || e.ErrorText == "net::ERR_BLOCKED_BY_CLIENT"
+}
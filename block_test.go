@@ -0,0 +1,107 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func TestResourceBlockerBlocks(t *testing.T) {
+	t.Parallel()
+	b := &ResourceBlocker{
+		Types: []network.ResourceType{network.ResourceTypeImage, network.ResourceTypeFont},
+		URLs:  []URLMatcher{MatchDomain("tracker.example")},
+	}
+	tests := []struct {
+		name string
+		typ  network.ResourceType
+		url  string
+		want bool
+	}{
+		{
+			name: "blocked type",
+			typ:  network.ResourceTypeImage,
+			url:  "https://example.com/image.png",
+			want: true,
+		},
+		{
+			name: "blocked domain",
+			typ:  network.ResourceTypeScript,
+			url:  "https://cdn.tracker.example/track.js",
+			want: true,
+		},
+		{
+			name: "not blocked",
+			typ:  network.ResourceTypeDocument,
+			url:  "https://example.com/",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Blocks(tt.typ, tt.url); got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBlocked(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		ev   *network.EventLoadingFailed
+		want bool
+	}{
+		{
+			name: "blocked by client",
+			ev:   &network.EventLoadingFailed{ErrorText: "net::ERR_BLOCKED_BY_CLIENT"},
+			want: true,
+		},
+		{
+			name: "blocked reason",
+			ev:   &network.EventLoadingFailed{ErrorText: "net::ERR_FAILED", BlockedReason: network.BlockedReasonInspector},
+			want: true,
+		},
+		{
+			name: "failed",
+			ev:   &network.EventLoadingFailed{ErrorText: "net::ERR_CONNECTION_REFUSED"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBlocked(tt.ev); got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockResources(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	b := &ResourceBlocker{
+		Types: []network.ResourceType{network.ResourceTypeImage},
+	}
+	var got string
+	tasks := chromedp.Tasks{
+		BlockResources(b),
+		chromedp.Navigate(endpoint + "/navigate.html"),
+		chromedp.Text("#text", &got),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	const want = "loaded"
+	if got != want {
+		t.Fatalf("expected text to be %q, got %q", want, got)
+	}
+	if n := b.Blocked(); n != 1 {
+		t.Fatalf("expected 1 blocked request, got %d", n)
+	}
+}
@@ -0,0 +1,264 @@
+package helper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time, timers and tickers to the helper actions.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a timer created by Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a ticker created by Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type clockKey struct{}
+
+// WithClock returns a copy of ctx in which the helper actions use c.
+//
+// The helper actions use RealClock by default.
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+func clock(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok && c != nil {
+		return c
+	}
+	return realClock{}
+}
+
+// withTimeout is like context.WithTimeout, but the timeout is measured by the clock of ctx.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	c := clock(ctx)
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	tctx := &timeoutContext{Context: ctx, deadline: c.Now().Add(timeout), done: make(chan struct{})}
+	timer := c.NewTimer(timeout)
+	canceled := make(chan struct{})
+	var once sync.Once
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			tctx.finish(context.DeadlineExceeded)
+		case <-ctx.Done():
+			tctx.finish(ctx.Err())
+		case <-canceled:
+			tctx.finish(context.Canceled)
+		}
+	}()
+	return tctx, func() { once.Do(func() { close(canceled) }) }
+}
+
+// timeoutContext is a context whose deadline is on a Clock other than RealClock.
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+	mu       sync.Mutex
+	err      error
+}
+
+func (c *timeoutContext) finish(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) { return c.deadline, true }
+func (c *timeoutContext) Done() <-chan struct{}       { return c.done }
+
+func (c *timeoutContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// RealClock returns the Clock of the time package.
+func RealClock() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{t: time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{t: time.NewTicker(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// FakeClock is a Clock whose time advances only by Advance or Set.
+//
+// It is intended for tests of timeouts and retries.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+var _ Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a new FakeClock whose current time is now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now implements Clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer implements Clock.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return fakeTimer{c.add(d, 0)}
+}
+
+// NewTicker implements Clock.
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{c.add(d, d)}
+}
+
+func (c *FakeClock) add(d, period time.Duration) *fakeWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{c: c, ch: make(chan time.Time, 1), at: c.now.Add(d), period: period}
+	c.waiters = append(c.waiters, w)
+	c.fire()
+	c.cond.Broadcast()
+	return w
+}
+
+// Advance advances the current time by d and fires the timers and tickers which are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Set sets the current time to t and fires the timers and tickers which are due.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fire()
+}
+
+// Waiters returns the number of active timers and tickers.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until at least n timers and tickers are active.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// fire sends the current time to the due waiters. c.mu must be held.
+func (c *FakeClock) fire() {
+	active := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			active = append(active, w)
+			continue
+		}
+		// drop the tick if the previous one is not received like time.Ticker
+		select {
+		case w.ch <- c.now:
+		default:
+		}
+		if w.period > 0 {
+			for !w.at.After(c.now) {
+				w.at = w.at.Add(w.period)
+			}
+			active = append(active, w)
+		}
+	}
+	for i := len(active); i < len(c.waiters); i++ {
+		c.waiters[i] = nil
+	}
+	c.waiters = active
+}
+
+func (c *FakeClock) remove(w *fakeWaiter) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, v := range c.waiters {
+		if v == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeWaiter struct {
+	c      *FakeClock
+	ch     chan time.Time
+	at     time.Time
+	period time.Duration
+}
+
+type fakeTimer struct {
+	w *fakeWaiter
+}
+
+func (t fakeTimer) C() <-chan time.Time { return t.w.ch }
+func (t fakeTimer) Stop() bool          { return t.w.c.remove(t.w) }
+
+type fakeTicker struct {
+	w *fakeWaiter
+}
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()               { t.w.c.remove(t.w) }
@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	timer := c.NewTimer(time.Minute)
+	ticker := c.NewTicker(10 * time.Second)
+	if c.Waiters() != 2 {
+		t.Fatalf("%#v != %#v", c.Waiters(), 2)
+	}
+	c.Advance(30 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired too early")
+	default:
+	}
+	select {
+	case got := <-ticker.C():
+		if want := start.Add(30 * time.Second); !got.Equal(want) {
+			t.Fatalf("%v != %v", got, want)
+		}
+	default:
+		t.Fatal("ticker did not fire")
+	}
+	c.Set(start.Add(time.Minute))
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("timer did not fire")
+	}
+	if timer.Stop() {
+		t.Fatal("fired timer must not be active")
+	}
+	if c.Waiters() != 1 {
+		t.Fatalf("%#v != %#v", c.Waiters(), 1)
+	}
+	ticker.Stop()
+	if c.Waiters() != 0 {
+		t.Fatalf("%#v != %#v", c.Waiters(), 0)
+	}
+
+	past := c.NewTimer(-time.Second)
+	select {
+	case <-past.C():
+	default:
+		t.Fatal("timer of the past did not fire")
+	}
+}
+
+func TestWaitForTimeFakeClock(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	ctx := WithClock(context.Background(), c)
+
+	ch := make(chan error, 1)
+	go func() {
+		ch <- WaitForTime(start.Add(time.Hour)).Do(ctx)
+	}()
+	c.BlockUntil(1)
+	c.Advance(59 * time.Minute)
+	select {
+	case err := <-ch:
+		t.Fatalf("returned too early: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.Advance(time.Minute)
+	if err := <-ch; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitLoadedFakeClock(t *testing.T) {
+	t.Parallel()
+	// no browser is needed because the load event never fires
+	ctx, cancel := chromedp.NewContext(context.Background())
+	defer cancel()
+
+	c := NewFakeClock(time.Now())
+	ch := make(chan error, 1)
+	go func() {
+		ch <- WaitLoaded(time.Hour).Do(WithTimeoutErrors(WithClock(ctx, c)))
+	}()
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	var terr *TimeoutError
+	if err := <-ch; !errors.As(err, &terr) || terr.Action != "WaitLoaded" {
+		t.Fatalf("expected TimeoutError, got %v", err)
+	}
+}
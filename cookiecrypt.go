@@ -0,0 +1,163 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	encryptedCookiesMagic = "cdph-cookies-v1\n"
+	encryptedCookiesSalt  = 16
+)
+
+var (
+	// ErrInvalidCookieFile is an error because of a malformed encrypted cookie file.
+	ErrInvalidCookieFile = errors.New("invalid encrypted cookie file")
+)
+
+// KeyProvider provides the key to encrypt and decrypt cookie files.
+type KeyProvider interface {
+	// Key returns the AES key of 16, 24 or 32 bytes for salt stored in the file.
+	Key(ctx context.Context, salt []byte) ([]byte, error)
+}
+
+// KeyProviderFunc is an adapter to allow the use of ordinary functions as KeyProvider.
+type KeyProviderFunc func(ctx context.Context, salt []byte) ([]byte, error)
+
+// Key calls f(ctx, salt).
+func (f KeyProviderFunc) Key(ctx context.Context, salt []byte) ([]byte, error) {
+	return f(ctx, salt)
+}
+
+// StaticKey returns a KeyProvider which always provides key.
+func StaticKey(key []byte) KeyProvider {
+	return KeyProviderFunc(func(context.Context, []byte) ([]byte, error) {
+		return key, nil
+	})
+}
+
+// PassphraseKey returns a KeyProvider which derives a 32 bytes key from passphrase by scrypt.
+func PassphraseKey(passphrase string) KeyProvider {
+	return KeyProviderFunc(func(_ context.Context, salt []byte) ([]byte, error) {
+		return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	})
+}
+
+// EncryptedCookieStore is a CookieStore which stores cookies as json lines file encrypted by AES-GCM.
+//
+// The file is written atomically with mode 0600.
+type EncryptedCookieStore struct {
+	filename interface{}
+	keys     KeyProvider
+}
+
+// NewEncryptedCookieStore returns a new EncryptedCookieStore.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func NewEncryptedCookieStore(filename interface{}, keys KeyProvider) *EncryptedCookieStore {
+	return &EncryptedCookieStore{filename: filename, keys: keys}
+}
+
+// Filename returns the name of the file.
+func (s *EncryptedCookieStore) Filename() string {
+	return toString(s.filename)
+}
+
+// Load implements CookieStore.
+func (s *EncryptedCookieStore) Load(ctx context.Context) ([]*network.Cookie, error) {
+	b, err := ioutil.ReadFile(s.Filename())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	headerSize := len(encryptedCookiesMagic) + encryptedCookiesSalt
+	if len(b) < headerSize || string(b[:len(encryptedCookiesMagic)]) != encryptedCookiesMagic {
+		return nil, ErrInvalidCookieFile
+	}
+	header, salt := b[:headerSize], b[len(encryptedCookiesMagic):headerSize]
+	aead, err := s.aead(ctx, salt)
+	if err != nil {
+		return nil, err
+	}
+	rest := b[headerSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrInvalidCookieFile
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt cookie file: %w", err)
+	}
+	return readCookies(bytes.NewReader(plaintext))
+}
+
+// Save implements CookieStore.
+func (s *EncryptedCookieStore) Save(ctx context.Context, cookies []*network.Cookie) error {
+	salt := make([]byte, encryptedCookiesSalt)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	aead, err := s.aead(ctx, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	var plaintext bytes.Buffer
+	if err := writeCookies(&plaintext, cookies); err != nil {
+		return err
+	}
+	header := append([]byte(encryptedCookiesMagic), salt...)
+	return writeFileAtomic(s.Filename(), 0600, func(w io.Writer) error {
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		if _, err := w.Write(nonce); err != nil {
+			return err
+		}
+		_, err := w.Write(aead.Seal(nil, nonce, plaintext.Bytes(), header))
+		return err
+	})
+}
+
+func (s *EncryptedCookieStore) aead(ctx context.Context, salt []byte) (cipher.AEAD, error) {
+	key, err := s.keys.Key(ctx, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SaveEncryptedCookies is an action that saves cookies as encrypted json lines file.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func SaveEncryptedCookies(filename interface{}, keys KeyProvider, maps ...func(*network.Cookie)) chromedp.Action {
+	return SaveCookiesTo(NewEncryptedCookieStore(filename, keys), maps...)
+}
+
+// RestoreEncryptedCookies is an action that restores cookies from encrypted json lines file.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func RestoreEncryptedCookies(filename interface{}, keys KeyProvider, filters ...func(*network.Cookie) bool) chromedp.Action {
+	return RestoreCookiesFrom(NewEncryptedCookieStore(filename, keys), filters...)
+}
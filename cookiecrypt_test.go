@@ -0,0 +1,88 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedCookieStore(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		keys  KeyProvider
+		wrong KeyProvider
+	}{
+		{
+			name:  "static key",
+			keys:  StaticKey(bytes.Repeat([]byte{1}, 32)),
+			wrong: StaticKey(bytes.Repeat([]byte{2}, 32)),
+		},
+		{
+			name:  "passphrase",
+			keys:  PassphraseKey("passphrase"),
+			wrong: PassphraseKey("wrong"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpath := filepath.Join(dir, tt.name+".enc")
+			store := NewEncryptedCookieStore(cpath, tt.keys)
+			testCookieStore(t, store)
+
+			b, err := ioutil.ReadFile(cpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(b, []byte("testval01")) {
+				t.Fatal("expected cookie values to be encrypted")
+			}
+			fi, err := os.Stat(cpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := fi.Mode().Perm(); perm != 0600 {
+				t.Fatalf("expected mode to be 0600, got %o", perm)
+			}
+
+			if _, err := NewEncryptedCookieStore(cpath, tt.wrong).Load(ctx); err == nil {
+				t.Fatal("expected error with wrong key")
+			}
+		})
+	}
+}
+
+func TestEncryptedCookieStoreInvalidFile(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	keys := StaticKey(bytes.Repeat([]byte{1}, 32))
+
+	got, err := NewEncryptedCookieStore(filepath.Join(dir, "not-found.enc"), keys).Load(ctx)
+	if err != nil || got != nil {
+		t.Fatalf("expected no cookies without error, got %v, %v", got, err)
+	}
+
+	cpath := filepath.Join(dir, "plain.jsonl")
+	if err := ioutil.WriteFile(cpath, []byte(`{"name":"a","value":"b"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEncryptedCookieStore(cpath, keys).Load(ctx); !errors.Is(err, ErrInvalidCookieFile) {
+		t.Fatalf("expected ErrInvalidCookieFile, got %v", err)
+	}
+}
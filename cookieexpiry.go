@@ -0,0 +1,69 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// isSessionCookie reports whether c is a session cookie which expires with the browser session.
+func isSessionCookie(c *network.Cookie) bool {
+	return c.Session || c.Expires <= 0
+}
+
+// cookieExpiresTime returns the expiration of the persistent cookie.
+func cookieExpiresTime(c *network.Cookie) time.Time {
+	sec := int64(c.Expires)
+	nsec := int64((c.Expires - float64(sec)) * float64(time.Second))
+	return time.Unix(sec, nsec)
+}
+
+// cookieExpires returns the expiration to set c to the browser.
+// It returns nil for session cookies.
+func cookieExpires(c *network.Cookie) *cdp.TimeSinceEpoch {
+	if isSessionCookie(c) {
+		return nil
+	}
+	expires := cdp.TimeSinceEpoch(cookieExpiresTime(c))
+	return &expires
+}
+
+func setCookieExpires(c *network.Cookie, t time.Time) {
+	c.Expires = float64(t.UnixNano()) / float64(time.Second)
+}
+
+// SkipExpired returns a filter for RestoreCookies which skips expired persistent cookies.
+func SkipExpired() func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		return isSessionCookie(c) || cookieExpiresTime(c).After(time.Now())
+	}
+}
+
+// ExtendExpires returns a filter for RestoreCookies which extends expirations of persistent cookies by d.
+//
+// It keeps all cookies.
+func ExtendExpires(d time.Duration) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		if !isSessionCookie(c) {
+			setCookieExpires(c, cookieExpiresTime(c).Add(d))
+		}
+		return true
+	}
+}
+
+// CapExpires returns a filter for RestoreCookies which caps expirations of persistent cookies to max from now.
+//
+// It keeps all cookies.
+func CapExpires(max time.Duration) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		if isSessionCookie(c) {
+			return true
+		}
+		limit := time.Now().Add(max)
+		if cookieExpiresTime(c).After(limit) {
+			setCookieExpires(c, limit)
+		}
+		return true
+	}
+}
@@ -0,0 +1,144 @@
+package helper
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func TestCookieExpires(t *testing.T) {
+	t.Parallel()
+	if got := cookieExpires(&network.Cookie{Expires: -1, Session: true}); got != nil {
+		t.Fatalf("expected nil for session cookie, got %v", got.Time())
+	}
+	got := cookieExpires(&network.Cookie{Expires: 1893456000.5})
+	want := time.Unix(1893456000, int64(500*time.Millisecond))
+	if got == nil || !got.Time().Equal(want) {
+		t.Fatalf("%v != %v", got, want)
+	}
+}
+
+func TestCookieExpiryFilters(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	persistent := func(t time.Time) *network.Cookie {
+		return &network.Cookie{Expires: float64(t.Unix())}
+	}
+	session := func() *network.Cookie {
+		return &network.Cookie{Expires: -1, Session: true}
+	}
+	tests := []struct {
+		name        string
+		filter      func(*network.Cookie) bool
+		cookie      *network.Cookie
+		want        bool
+		wantExpires float64
+	}{
+		{
+			name:        "skip expired",
+			filter:      SkipExpired(),
+			cookie:      persistent(now.Add(-time.Hour)),
+			want:        false,
+			wantExpires: float64(now.Add(-time.Hour).Unix()),
+		},
+		{
+			name:        "keep not expired",
+			filter:      SkipExpired(),
+			cookie:      persistent(now.Add(time.Hour)),
+			want:        true,
+			wantExpires: float64(now.Add(time.Hour).Unix()),
+		},
+		{
+			name:        "keep session",
+			filter:      SkipExpired(),
+			cookie:      session(),
+			want:        true,
+			wantExpires: -1,
+		},
+		{
+			name:        "extend",
+			filter:      ExtendExpires(time.Hour),
+			cookie:      persistent(now),
+			want:        true,
+			wantExpires: float64(now.Add(time.Hour).Unix()),
+		},
+		{
+			name:        "extend session",
+			filter:      ExtendExpires(time.Hour),
+			cookie:      session(),
+			want:        true,
+			wantExpires: -1,
+		},
+		{
+			name:        "cap",
+			filter:      CapExpires(time.Hour),
+			cookie:      persistent(now.Add(24 * time.Hour)),
+			want:        true,
+			wantExpires: float64(now.Add(time.Hour).Unix()),
+		},
+		{
+			name:        "not capped",
+			filter:      CapExpires(time.Hour),
+			cookie:      persistent(now.Add(time.Minute)),
+			want:        true,
+			wantExpires: float64(now.Add(time.Minute).Unix()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter(tt.cookie); got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+			// allow the difference of the clock during the test
+			if math.Abs(tt.cookie.Expires-tt.wantExpires) > 1 {
+				t.Fatalf("expected expires to be %v, got %v", tt.wantExpires, tt.cookie.Expires)
+			}
+		})
+	}
+}
+
+func TestRestorePersistentCookies(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocateSeparate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cpath := filepath.Join(dir, "cookies.jsonl")
+
+	var got []*network.Cookie
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(endpoint + "/persistent-cookies"),
+		SaveCookies(cpath),
+		network.ClearBrowserCookies(),
+		RestoreCookies(cpath, SkipExpired()),
+		chromedp.ActionFunc(func(ctx context.Context) (err error) {
+			got, err = network.GetAllCookies().Do(ctx)
+			return
+		}),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("invalid length\nwant: %d, got: %d", 1, len(got))
+	}
+	if got[0].Session {
+		t.Fatal("expected cookie to be persistent")
+	}
+	if want := float64(testCookieExpires.Unix()); math.Abs(got[0].Expires-want) > 1 {
+		t.Fatalf("expected expires to be %v, got %v", want, got[0].Expires)
+	}
+}
@@ -0,0 +1,153 @@
+package helper
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// ByDomain returns a filter for RestoreCookies which keeps cookies of domains or their subdomains.
+func ByDomain(domains ...string) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		for _, d := range domains {
+			if cookieDomainMatches(c.Domain, d) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ByName returns a filter for RestoreCookies which keeps cookies whose name matches re.
+func ByName(re *regexp.Regexp) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		return re.MatchString(c.Name)
+	}
+}
+
+// NotExpired returns a filter for RestoreCookies which keeps session cookies and unexpired persistent cookies.
+//
+// It is the same as SkipExpired.
+func NotExpired() func(*network.Cookie) bool {
+	return SkipExpired()
+}
+
+// HTTPOnlyOnly returns a filter for RestoreCookies which keeps HttpOnly cookies.
+func HTTPOnlyOnly() func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		return c.HTTPOnly
+	}
+}
+
+// SecureOnly returns a filter for RestoreCookies which keeps secure cookies.
+func SecureOnly() func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		return c.Secure
+	}
+}
+
+// SameSite returns a filter for RestoreCookies which keeps cookies whose SameSite attribute is any of values.
+//
+// Use the empty value to keep cookies without SameSite attribute.
+func SameSite(values ...network.CookieSameSite) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		for _, v := range values {
+			if c.SameSite == v {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a filter for RestoreCookies which keeps cookies not kept by f.
+func Not(f func(*network.Cookie) bool) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		return !f(c)
+	}
+}
+
+// And returns a filter for RestoreCookies which keeps cookies kept by all of filters.
+//
+// filters are applied in order until a filter does not keep the cookie.
+func And(filters ...func(*network.Cookie) bool) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		for _, f := range filters {
+			if !f(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a filter for RestoreCookies which keeps cookies kept by any of filters.
+//
+// filters are applied in order until a filter keeps the cookie.
+func Or(filters ...func(*network.Cookie) bool) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		for _, f := range filters {
+			if f(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Map returns a filter for RestoreCookies which applies maps to the cookie and keeps all cookies.
+//
+// It allows to use the maps of SaveCookies as filters.
+func Map(maps ...func(*network.Cookie)) func(*network.Cookie) bool {
+	return func(c *network.Cookie) bool {
+		for _, m := range maps {
+			m(c)
+		}
+		return true
+	}
+}
+
+// SetPriority returns a map for SaveCookies which sets the priority of cookies to p.
+func SetPriority(p network.CookiePriority) func(*network.Cookie) {
+	return func(c *network.Cookie) {
+		c.Priority = p
+	}
+}
+
+// RewriteDomain returns a map for SaveCookies which rewrites the domain of cookies from from to to.
+//
+// Subdomains of from are rewritten to the subdomains of to.
+// The leading dot of domain cookies is preserved.
+func RewriteDomain(from, to string) func(*network.Cookie) {
+	from = strings.TrimPrefix(from, ".")
+	to = strings.TrimPrefix(to, ".")
+	return func(c *network.Cookie) {
+		dot := ""
+		d := c.Domain
+		if strings.HasPrefix(d, ".") {
+			dot = "."
+			d = d[1:]
+		}
+		switch {
+		case d == from:
+			c.Domain = dot + to
+		case strings.HasSuffix(d, "."+from):
+			c.Domain = dot + strings.TrimSuffix(d, from) + to
+		}
+	}
+}
+
+// StripSession returns a map for SaveCookies which turns session cookies into persistent cookies expiring d from now.
+//
+// It allows to keep session cookies across browser sessions.
+func StripSession(d time.Duration) func(*network.Cookie) {
+	return func(c *network.Cookie) {
+		if !isSessionCookie(c) {
+			return
+		}
+		c.Session = false
+		setCookieExpires(c, time.Now().Add(d))
+	}
+}
@@ -0,0 +1,100 @@
+package helper
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestCookieFilters(t *testing.T) {
+	t.Parallel()
+	c := &network.Cookie{
+		Name:     "session_id",
+		Domain:   ".www.example.com",
+		Expires:  float64(time.Now().Add(time.Hour).Unix()),
+		HTTPOnly: true,
+		SameSite: network.CookieSameSiteLax,
+	}
+	yes := func(*network.Cookie) bool { return true }
+	no := func(*network.Cookie) bool { return false }
+	tests := []struct {
+		name   string
+		filter func(*network.Cookie) bool
+		want   bool
+	}{
+		{name: "ByDomain", filter: ByDomain("example.com"), want: true},
+		{name: "ByDomain exact", filter: ByDomain("www.example.com"), want: true},
+		{name: "ByDomain other", filter: ByDomain("example.org", "ample.com"), want: false},
+		{name: "ByName", filter: ByName(regexp.MustCompile(`^session`)), want: true},
+		{name: "ByName unmatched", filter: ByName(regexp.MustCompile(`^token$`)), want: false},
+		{name: "NotExpired", filter: NotExpired(), want: true},
+		{name: "HTTPOnlyOnly", filter: HTTPOnlyOnly(), want: true},
+		{name: "SecureOnly", filter: SecureOnly(), want: false},
+		{name: "SameSite", filter: SameSite(network.CookieSameSiteStrict, network.CookieSameSiteLax), want: true},
+		{name: "SameSite unmatched", filter: SameSite(network.CookieSameSiteNone), want: false},
+		{name: "Not", filter: Not(SecureOnly()), want: true},
+		{name: "And", filter: And(yes, HTTPOnlyOnly()), want: true},
+		{name: "And false", filter: And(yes, no), want: false},
+		{name: "And empty", filter: And(), want: true},
+		{name: "Or", filter: Or(no, SecureOnly(), HTTPOnlyOnly()), want: true},
+		{name: "Or false", filter: Or(no, SecureOnly()), want: false},
+		{name: "Or empty", filter: Or(), want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.filter(c); got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieMaps(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		m      func(*network.Cookie)
+		domain string
+		want   string
+	}{
+		{name: "exact", m: RewriteDomain("example.com", "example.org"), domain: "example.com", want: "example.org"},
+		{name: "dot", m: RewriteDomain(".example.com", "example.org"), domain: ".example.com", want: ".example.org"},
+		{name: "subdomain", m: RewriteDomain("example.com", "test.local"), domain: ".www.example.com", want: ".www.test.local"},
+		{name: "other", m: RewriteDomain("example.com", "example.org"), domain: "badexample.com", want: "badexample.com"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := &network.Cookie{Domain: tt.domain}
+			tt.m(c)
+			if c.Domain != tt.want {
+				t.Fatalf("%#v != %#v", c.Domain, tt.want)
+			}
+		})
+	}
+
+	c := &network.Cookie{Expires: -1, Session: true}
+	if !Map(SetPriority(network.CookiePriorityHigh), StripSession(time.Hour))(c) {
+		t.Fatal("Map must keep the cookie")
+	}
+	if c.Priority != network.CookiePriorityHigh {
+		t.Fatalf("%#v != %#v", c.Priority, network.CookiePriorityHigh)
+	}
+	if c.Session || isSessionCookie(c) {
+		t.Fatalf("session is not stripped: %+v", c)
+	}
+	if d := time.Until(cookieExpiresTime(c)); d < 59*time.Minute || d > time.Hour {
+		t.Fatalf("invalid expiration: %v", d)
+	}
+
+	persistent := &network.Cookie{Expires: 1893456000}
+	StripSession(time.Hour)(persistent)
+	if persistent.Expires != 1893456000 {
+		t.Fatalf("persistent cookie is modified: %+v", persistent)
+	}
+}
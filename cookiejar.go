@@ -0,0 +1,282 @@
+package helper
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// HTTPCookie converts c to *http.Cookie.
+func HTTPCookie(c *network.Cookie) *http.Cookie {
+	hc := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+	}
+	// host-only cookies have no leading dot
+	if strings.HasPrefix(c.Domain, ".") {
+		hc.Domain = strings.TrimPrefix(c.Domain, ".")
+	}
+	if !c.Session && c.Expires > 0 {
+		hc.Expires = time.Unix(int64(c.Expires), 0).UTC()
+	}
+	switch c.SameSite {
+	case network.CookieSameSiteStrict:
+		hc.SameSite = http.SameSiteStrictMode
+	case network.CookieSameSiteLax:
+		hc.SameSite = http.SameSiteLaxMode
+	case network.CookieSameSiteNone:
+		hc.SameSite = http.SameSiteNoneMode
+	}
+	return hc
+}
+
+// NetworkCookie converts c received from u to *network.Cookie.
+func NetworkCookie(u *url.URL, c *http.Cookie) *network.Cookie {
+	nc := &network.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   u.Hostname(),
+		Path:     c.Path,
+		Expires:  -1,
+		Size:     int64(len(c.Name) + len(c.Value)),
+		HTTPOnly: c.HttpOnly,
+		Secure:   c.Secure,
+		Session:  true,
+		Priority: network.CookiePriorityMedium,
+	}
+	if c.Domain != "" {
+		nc.Domain = "." + strings.TrimPrefix(c.Domain, ".")
+	}
+	if nc.Path == "" || !strings.HasPrefix(nc.Path, "/") {
+		nc.Path = defaultCookiePath(u.Path)
+	}
+	switch {
+	case c.MaxAge > 0:
+		nc.Expires = float64(time.Now().Add(time.Duration(c.MaxAge) * time.Second).Unix())
+		nc.Session = false
+	case c.MaxAge < 0:
+		nc.Expires = 0
+		nc.Session = false
+	case !c.Expires.IsZero():
+		nc.Expires = float64(c.Expires.Unix())
+		nc.Session = false
+	}
+	switch c.SameSite {
+	case http.SameSiteStrictMode:
+		nc.SameSite = network.CookieSameSiteStrict
+	case http.SameSiteLaxMode:
+		nc.SameSite = network.CookieSameSiteLax
+	case http.SameSiteNoneMode:
+		nc.SameSite = network.CookieSameSiteNone
+	}
+	return nc
+}
+
+// defaultCookiePath returns the default path of cookies defined in RFC 6265 section 5.1.4.
+func defaultCookiePath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	dir := path.Dir(p)
+	if strings.HasSuffix(p, "/") {
+		dir = strings.TrimSuffix(p, "/")
+	}
+	if dir == "" || dir == "." {
+		return "/"
+	}
+	return dir
+}
+
+// CookieJar is a http.CookieJar which holds cookies as network.Cookie.
+//
+// It implements CookieStore, so it can be populated from the browser by SaveCookiesTo
+// and pushed back to the browser by RestoreCookiesFrom.
+// The zero value is an empty jar.
+//
+// Cookies whose Domain attribute does not domain-match the host of the response are rejected
+// as defined in RFC 6265 section 5.3.
+type CookieJar struct {
+	// PublicSuffixList rejects cookies for public suffixes such as "co.uk" if it is not nil.
+	// golang.org/x/net/publicsuffix.List can be used.
+	PublicSuffixList cookiejar.PublicSuffixList
+
+	mu      sync.Mutex
+	cookies []*network.Cookie
+}
+
+var _ http.CookieJar = (*CookieJar)(nil)
+var _ CookieStore = (*CookieJar)(nil)
+
+// SetCookies implements http.CookieJar.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, c := range cookies {
+		domain, ok := j.domain(u, c)
+		if !ok {
+			log.Printf("CookieJar: reject cookie name=%s domain=%s host=%s\n", c.Name, c.Domain, u.Hostname())
+			continue
+		}
+		nc := NetworkCookie(u, c)
+		nc.Domain = domain
+		j.remove(nc)
+		if !nc.Session && nc.Expires <= float64(time.Now().Unix()) {
+			continue
+		}
+		j.cookies = append(j.cookies, nc)
+	}
+}
+
+// domain returns the domain of the cookie c received from u, which has a leading dot unless it is host-only.
+// It reports false if u cannot set c.
+func (j *CookieJar) domain(u *url.URL, c *http.Cookie) (string, bool) {
+	host := strings.ToLower(u.Hostname())
+	if c.Domain == "" {
+		return host, true
+	}
+	domain := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+	if domain == "" || strings.HasSuffix(domain, ".") {
+		return "", false
+	}
+	if net.ParseIP(host) != nil {
+		// IP hosts can set only host-only cookies
+		return host, domain == host
+	}
+	if j.PublicSuffixList != nil && j.PublicSuffixList.PublicSuffix(domain) == domain {
+		// cookies for a public suffix are allowed only as host-only cookies of the suffix itself
+		return host, domain == host
+	}
+	if host != domain && !strings.HasSuffix(host, "."+domain) {
+		return "", false
+	}
+	return "." + domain, true
+}
+
+// Cookies implements http.CookieJar.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	cookies := j.cookiesFor(u)
+	res := make([]*http.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		res = append(res, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return res
+}
+
+// cookiesFor returns copies of the unexpired cookies sent to u.
+func (j *CookieJar) cookiesFor(u *url.URL) []*network.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := float64(time.Now().Unix())
+	var res []*network.Cookie
+	for _, c := range j.cookies {
+		if !c.Session && c.Expires <= now {
+			continue
+		}
+		if !cookieMatchesURL(c, u) {
+			continue
+		}
+		res = append(res, c)
+	}
+	return copyCookies(res)
+}
+
+// Load implements CookieStore.
+func (j *CookieJar) Load(ctx context.Context) ([]*network.Cookie, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return copyCookies(j.cookies), nil
+}
+
+// Save implements CookieStore.
+func (j *CookieJar) Save(ctx context.Context, cookies []*network.Cookie) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.cookies = copyCookies(cookies)
+	return nil
+}
+
+// remove removes the cookie which has the same name, domain and path with c.
+func (j *CookieJar) remove(c *network.Cookie) {
+	for i, jc := range j.cookies {
+		if jc.Name == c.Name && jc.Domain == c.Domain && jc.Path == c.Path {
+			j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+			return
+		}
+	}
+}
+
+// cookieMatchesURL reports whether c is sent to u.
+func cookieMatchesURL(c *network.Cookie, u *url.URL) bool {
+	if c.Secure && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if strings.HasPrefix(c.Domain, ".") {
+		domain := strings.TrimPrefix(c.Domain, ".")
+		if host != domain && !strings.HasSuffix(host, c.Domain) {
+			return false
+		}
+	} else if host != c.Domain {
+		return false
+	}
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	if p == c.Path {
+		return true
+	}
+	return strings.HasPrefix(p, c.Path) &&
+		(strings.HasSuffix(c.Path, "/") || p[len(c.Path)] == '/')
+}
+
+// PushCookieJar is an action that sets the cookies of jar for urls to the browser.
+//
+// If jar is *CookieJar, the cookies are set with all their attributes.
+// Otherwise only the names and values are available, so the cookies are set as host-only session cookies of the urls.
+//
+// urls can be specified by string, string pointer or fmt.Stringer.
+func PushCookieJar(jar http.CookieJar, urls ...interface{}) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, urlstr := range urls {
+			s := toString(urlstr)
+			u, err := url.Parse(s)
+			if err != nil {
+				return err
+			}
+			if j, ok := jar.(*CookieJar); ok {
+				cookies := j.cookiesFor(u)
+				log.Printf("PushCookieJar: cookie(s)=%d url=%s\n", len(cookies), s)
+				if err := setCookies(ctx, cookies); err != nil {
+					return err
+				}
+				continue
+			}
+			cookies := jar.Cookies(u)
+			log.Printf("PushCookieJar: cookie(s)=%d url=%s\n", len(cookies), s)
+			for _, c := range cookies {
+				success, err := network.SetCookie(c.Name, c.Value).WithURL(s).Do(ctx)
+				if err != nil {
+					return err
+				}
+				if !success {
+					return &CookieError{Name: c.Name, Value: c.Value, Domain: u.Hostname()}
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,263 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func TestHTTPCookie(t *testing.T) {
+	t.Parallel()
+	got := HTTPCookie(&network.Cookie{
+		Name:     "name",
+		Value:    "value",
+		Domain:   ".example.com",
+		Path:     "/path",
+		Expires:  1893456000,
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: network.CookieSameSiteLax,
+	})
+	want := &http.Cookie{
+		Name:     "name",
+		Value:    "value",
+		Domain:   "example.com",
+		Path:     "/path",
+		Expires:  time.Unix(1893456000, 0).UTC(),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("\nwant: %+v\n got: %+v", want, got)
+	}
+}
+
+func TestNetworkCookie(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("https://www.example.com/dir/page")
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		want   *network.Cookie
+	}{
+		{
+			name:   "host-only session cookie",
+			cookie: &http.Cookie{Name: "a", Value: "b"},
+			want: &network.Cookie{
+				Name:     "a",
+				Value:    "b",
+				Domain:   "www.example.com",
+				Path:     "/dir",
+				Expires:  -1,
+				Size:     2,
+				Session:  true,
+				Priority: network.CookiePriorityMedium,
+			},
+		},
+		{
+			name: "domain cookie",
+			cookie: &http.Cookie{
+				Name:     "a",
+				Value:    "b",
+				Domain:   "example.com",
+				Path:     "/",
+				Expires:  time.Unix(1893456000, 0),
+				SameSite: http.SameSiteStrictMode,
+			},
+			want: &network.Cookie{
+				Name:     "a",
+				Value:    "b",
+				Domain:   ".example.com",
+				Path:     "/",
+				Expires:  1893456000,
+				Size:     2,
+				SameSite: network.CookieSameSiteStrict,
+				Priority: network.CookiePriorityMedium,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NetworkCookie(u, tt.cookie)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("\nwant: %+v\n got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultCookiePath(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"":          "/",
+		"/":         "/",
+		"/page":     "/",
+		"/dir/page": "/dir",
+		"/dir/":     "/dir",
+	}
+	for p, want := range tests {
+		if got := defaultCookiePath(p); got != want {
+			t.Fatalf("%s: %#v != %#v", p, got, want)
+		}
+	}
+}
+
+func TestCookieJar(t *testing.T) {
+	t.Parallel()
+	jar := &CookieJar{}
+	u, _ := url.Parse("https://www.example.com/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: "example.com"},
+		{Name: "secure", Value: "3", Secure: true},
+		{Name: "path", Value: "4", Path: "/path"},
+		{Name: "expired", Value: "5", MaxAge: -1},
+	})
+	// replace the cookie of same name, domain and path
+	jar.SetCookies(u, []*http.Cookie{{Name: "host", Value: "6"}})
+
+	names := func(cookies []*http.Cookie) map[string]string {
+		res := make(map[string]string)
+		for _, c := range cookies {
+			res[c.Name] = c.Value
+		}
+		return res
+	}
+	tests := []struct {
+		url  string
+		want map[string]string
+	}{
+		{url: "https://www.example.com/", want: map[string]string{"host": "6", "domain": "2", "secure": "3"}},
+		{url: "http://www.example.com/path/to", want: map[string]string{"host": "6", "domain": "2", "path": "4"}},
+		{url: "https://sub.example.com/pathname", want: map[string]string{"domain": "2"}},
+		{url: "https://example.org/", want: map[string]string{}},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := names(jar.Cookies(u)); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s\nwant: %v\n got: %v", tt.url, tt.want, got)
+		}
+	}
+
+	cookies, err := jar.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 4 {
+		t.Fatalf("expected 4 cookies, got %d", len(cookies))
+	}
+}
+
+// testPublicSuffixList treats the last label and "co.uk" as public suffixes.
+type testPublicSuffixList struct{}
+
+func (testPublicSuffixList) PublicSuffix(domain string) string {
+	if domain == "co.uk" || strings.HasSuffix(domain, ".co.uk") {
+		return "co.uk"
+	}
+	return domain[strings.LastIndex(domain, ".")+1:]
+}
+
+func (testPublicSuffixList) String() string { return "test" }
+
+func TestCookieJarDomain(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		from   string
+		domain string
+		to     string
+		want   bool
+	}{
+		{name: "host only", from: "https://www.example.com/", to: "https://www.example.com/", want: true},
+		{name: "parent domain", from: "https://www.example.com/", domain: "example.com", to: "https://api.example.com/", want: true},
+		{name: "same domain with dot", from: "https://example.com/", domain: ".Example.com", to: "https://www.example.com/", want: true},
+		{name: "other domain", from: "https://evil.example/", domain: "bank.example", to: "https://bank.example/", want: false},
+		{name: "suffix without dot", from: "https://notexample.com/", domain: "example.com", to: "https://example.com/", want: false},
+		{name: "subdomain of host", from: "https://example.com/", domain: "www.example.com", to: "https://www.example.com/", want: false},
+		{name: "ip host", from: "http://127.0.0.1/", domain: "0.1", to: "http://127.0.0.1/", want: false},
+		{name: "ip host itself", from: "http://127.0.0.1/", domain: "127.0.0.1", to: "http://127.0.0.1/", want: true},
+		{name: "public suffix", from: "https://www.example.co.uk/", domain: "co.uk", to: "https://bank.co.uk/", want: false},
+		{name: "registrable domain", from: "https://www.example.co.uk/", domain: "example.co.uk", to: "https://example.co.uk/", want: true},
+		{name: "upper case host", from: "https://Example.com/", to: "https://EXAMPLE.com/", want: true},
+		{name: "upper case domain", from: "https://www.example.com/", domain: "example.com", to: "https://API.Example.com/", want: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			jar := &CookieJar{PublicSuffixList: testPublicSuffixList{}}
+			from, _ := url.Parse(tt.from)
+			jar.SetCookies(from, []*http.Cookie{{Name: "sid", Value: "1", Domain: tt.domain}})
+			to, _ := url.Parse(tt.to)
+			if got := len(jar.Cookies(to)) == 1; got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieJarHTTPClient(t *testing.T) {
+	t.Parallel()
+	endpoint := testStartServer(t)
+
+	jar := &CookieJar{}
+	client := &http.Client{Jar: jar}
+	res, err := client.Get(endpoint + "/cookies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	u, _ := url.Parse(endpoint + "/cookies")
+	if got := len(jar.Cookies(u)); got != 2 {
+		t.Fatalf("expected 2 cookies, got %d", got)
+	}
+}
+
+func TestPushCookieJar(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocateSeparate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	jar := &CookieJar{}
+	u, _ := url.Parse(endpoint + "/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "test-cookie-01", Value: "testval01", Path: "/"},
+		{Name: "test-cookie-02", Value: "testval02", Path: "/", HttpOnly: true, Expires: time.Now().Add(time.Hour)},
+	})
+
+	var status string
+	var cookies []*network.Cookie
+	tasks := chromedp.Tasks{
+		PushCookieJar(jar, endpoint+"/restore-cookies"),
+		chromedp.Navigate(endpoint + "/restore-cookies"),
+		chromedp.Text("body", &status),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = network.GetAllCookies().Do(ctx)
+			return err
+		}),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if status != "ok" {
+		t.Fatal("cookie check failed: status is not ok")
+	}
+	// the attributes of the cookies of CookieJar are kept
+	for _, c := range cookies {
+		if c.Name == "test-cookie-02" && (!c.HTTPOnly || c.Session) {
+			t.Fatalf("attributes are not kept: %+v", c)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package helper
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// MergeMode is the way to merge cookies into existing cookies.
+type MergeMode int
+
+const (
+	// MergeReplace replaces all existing cookies in the scope with the merged cookies.
+	MergeReplace MergeMode = iota
+	// MergeUpdate adds the merged cookies and updates existing cookies with the same name, domain and path.
+	// Other existing cookies are kept.
+	MergeUpdate
+	// MergeKeepNewer is MergeUpdate but keeps existing cookies which expire later than the merged cookies.
+	MergeKeepNewer
+)
+
+func (m MergeMode) String() string {
+	switch m {
+	case MergeReplace:
+		return "Replace"
+	case MergeUpdate:
+		return "Update"
+	case MergeKeepNewer:
+		return "KeepNewer"
+	}
+	return "Unknown"
+}
+
+// CookieScope limits cookies to save or restore.
+//
+// A cookie is in the scope if it is sent to any of URLs and its domain is any of Domains or their subdomains.
+// Empty URLs or Domains does not limit cookies.
+type CookieScope struct {
+	URLs    []string
+	Domains []string
+}
+
+// Contains reports whether c is in the scope. A nil scope contains all cookies.
+func (s *CookieScope) Contains(c *network.Cookie) bool {
+	if s == nil {
+		return true
+	}
+	if len(s.URLs) > 0 {
+		matched := false
+		for _, urlstr := range s.URLs {
+			u, err := url.Parse(urlstr)
+			if err == nil && cookieMatchesURL(c, u) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(s.Domains) > 0 {
+		matched := false
+		for _, d := range s.Domains {
+			if cookieDomainMatches(c.Domain, d) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// cookies returns the cookies of browser in the scope.
+func (s *CookieScope) cookies(ctx context.Context) ([]*network.Cookie, error) {
+	var cookies []*network.Cookie
+	var err error
+	if s != nil && len(s.URLs) > 0 {
+		cookies, err = network.GetCookies().WithUrls(s.URLs).Do(ctx)
+	} else {
+		cookies, err = network.GetAllCookies().Do(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*network.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		if s.Contains(c) {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+// CookieDiff is the changes made by merging cookies.
+type CookieDiff struct {
+	Added   []*network.Cookie
+	Updated []*network.Cookie
+	Removed []*network.Cookie
+}
+
+// Empty reports whether there is no change.
+func (d *CookieDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+// MergeCookies merges src into dst by mode and returns the merged cookies and the changes made to dst.
+//
+// Cookies of src out of scope are ignored, and cookies of dst out of scope are kept as they are.
+// Cookies are identified by name, domain and path. dst and src are not modified.
+func MergeCookies(dst, src []*network.Cookie, scope *CookieScope, mode MergeMode) ([]*network.Cookie, *CookieDiff) {
+	diff := &CookieDiff{}
+	res := copyCookies(dst)
+	index := make(map[cookieKey]int, len(res))
+	for i, c := range res {
+		index[keyOfCookie(c)] = i
+	}
+	merged := make(map[cookieKey]bool, len(src))
+	for _, c := range src {
+		if !scope.Contains(c) {
+			continue
+		}
+		k := keyOfCookie(c)
+		merged[k] = true
+		cc := *c
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(res)
+			res = append(res, &cc)
+			diff.Added = append(diff.Added, &cc)
+			continue
+		}
+		if cookieEqual(res[i], &cc) {
+			continue
+		}
+		if mode == MergeKeepNewer && cookieNewer(res[i], &cc) {
+			continue
+		}
+		res[i] = &cc
+		diff.Updated = append(diff.Updated, &cc)
+	}
+	if mode != MergeReplace {
+		return res, diff
+	}
+	kept := res[:0]
+	for _, c := range res {
+		if scope.Contains(c) && !merged[keyOfCookie(c)] {
+			diff.Removed = append(diff.Removed, c)
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept, diff
+}
+
+type cookieKey struct {
+	name, domain, path string
+}
+
+func keyOfCookie(c *network.Cookie) cookieKey {
+	return cookieKey{name: c.Name, domain: c.Domain, path: c.Path}
+}
+
+// cookieEqual reports whether a and b have the same value and attributes.
+func cookieEqual(a, b *network.Cookie) bool {
+	return a.Value == b.Value &&
+		isSessionCookie(a) == isSessionCookie(b) &&
+		(isSessionCookie(a) || a.Expires == b.Expires) &&
+		a.HTTPOnly == b.HTTPOnly &&
+		a.Secure == b.Secure &&
+		a.SameSite == b.SameSite
+}
+
+// cookieNewer reports whether persistent cookie a expires later than persistent cookie b.
+func cookieNewer(a, b *network.Cookie) bool {
+	if isSessionCookie(a) || isSessionCookie(b) {
+		return false
+	}
+	return a.Expires > b.Expires
+}
+
+// cookieDomainMatches reports whether cookieDomain is domain or its subdomain.
+func cookieDomainMatches(cookieDomain, domain string) bool {
+	cookieDomain = strings.TrimPrefix(cookieDomain, ".")
+	domain = strings.TrimPrefix(domain, ".")
+	return cookieDomain == domain || strings.HasSuffix(cookieDomain, "."+domain)
+}
+
+// MergeCookiesTo is an action that merges cookies of browser in scope into the cookies saved in store.
+//
+// The changes made to store are set to diff if it is not nil.
+func MergeCookiesTo(store CookieStore, scope *CookieScope, mode MergeMode, diff *CookieDiff, maps ...func(*network.Cookie)) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		ctx, span := startSpan(ctx, "SaveCookies")
+		defer func() { endSpan(span, err) }()
+
+		cookies, err := scope.cookies(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range cookies {
+			for _, m := range maps {
+				m(c)
+			}
+		}
+		saved, err := store.Load(ctx)
+		if err != nil {
+			return err
+		}
+		merged, d := MergeCookies(saved, cookies, scope, mode)
+		log.Printf("SaveCookies: mode=%s added=%d updated=%d removed=%d\n", mode, len(d.Added), len(d.Updated), len(d.Removed))
+		span.SetAttributes(AttrCookies.Int(len(merged)))
+		if err := store.Save(ctx, merged); err != nil {
+			return err
+		}
+		if diff != nil {
+			*diff = *d
+		}
+		return nil
+	})
+}
+
+// MergeCookiesFrom is an action that merges cookies in scope saved in store into the cookies of browser.
+//
+// filters are applied in order and can modify the cookie to restore.
+// The changes made to browser are set to diff if it is not nil.
+func MergeCookiesFrom(store CookieStore, scope *CookieScope, mode MergeMode, diff *CookieDiff, filters ...func(*network.Cookie) bool) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		ctx, span := startSpan(ctx, "RestoreCookies")
+		defer func() { endSpan(span, err) }()
+
+		loaded, err := store.Load(ctx)
+		if err != nil {
+			return err
+		}
+		cookies := make([]*network.Cookie, 0, len(loaded))
+	loop:
+		for _, c := range loaded {
+			for _, f := range filters {
+				if !f(c) {
+					continue loop
+				}
+			}
+			cookies = append(cookies, c)
+		}
+		current, err := scope.cookies(ctx)
+		if err != nil {
+			return err
+		}
+		_, d := MergeCookies(current, cookies, scope, mode)
+		log.Printf("RestoreCookies: mode=%s added=%d updated=%d removed=%d\n", mode, len(d.Added), len(d.Updated), len(d.Removed))
+		span.SetAttributes(AttrCookies.Int(len(d.Added) + len(d.Updated)))
+
+		for _, c := range d.Removed {
+			if err := network.DeleteCookies(c.Name).WithDomain(c.Domain).WithPath(c.Path).Do(ctx); err != nil {
+				return err
+			}
+		}
+		set := make([]*network.Cookie, 0, len(d.Added)+len(d.Updated))
+		set = append(append(set, d.Added...), d.Updated...)
+		if err := setCookies(ctx, set); err != nil {
+			return err
+		}
+		metrics(ctx).CookiesRestored(len(d.Added) + len(d.Updated))
+		if diff != nil {
+			*diff = *d
+		}
+		return nil
+	})
+}
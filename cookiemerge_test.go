@@ -0,0 +1,162 @@
+package helper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func testCookie(name, value, domain string, expires float64) *network.Cookie {
+	return &network.Cookie{
+		Name:    name,
+		Value:   value,
+		Domain:  domain,
+		Path:    "/",
+		Expires: expires,
+		Session: expires <= 0,
+	}
+}
+
+func cookieNames(cookies []*network.Cookie) []string {
+	res := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		res = append(res, c.Name+"="+c.Value+"@"+c.Domain)
+	}
+	return res
+}
+
+func TestMergeCookies(t *testing.T) {
+	t.Parallel()
+	dst := []*network.Cookie{
+		testCookie("a", "1", "example.com", 2000000000),
+		testCookie("b", "1", "example.com", -1),
+		testCookie("c", "1", "example.org", -1),
+	}
+	src := []*network.Cookie{
+		testCookie("a", "2", "example.com", 1900000000),
+		testCookie("b", "1", "example.com", -1),
+		testCookie("d", "1", "example.com", -1),
+		testCookie("e", "1", "example.net", -1),
+	}
+	scope := &CookieScope{Domains: []string{"example.com", "example.org"}}
+	tests := []struct {
+		mode    MergeMode
+		scope   *CookieScope
+		want    []string
+		added   []string
+		updated []string
+		removed []string
+	}{
+		{
+			mode:    MergeReplace,
+			scope:   scope,
+			want:    []string{"a=2@example.com", "b=1@example.com", "d=1@example.com"},
+			added:   []string{"d=1@example.com"},
+			updated: []string{"a=2@example.com"},
+			removed: []string{"c=1@example.org"},
+		},
+		{
+			mode:    MergeReplace,
+			scope:   &CookieScope{Domains: []string{"example.com"}},
+			want:    []string{"a=2@example.com", "b=1@example.com", "c=1@example.org", "d=1@example.com"},
+			added:   []string{"d=1@example.com"},
+			updated: []string{"a=2@example.com"},
+			removed: []string{},
+		},
+		{
+			mode:    MergeUpdate,
+			scope:   nil,
+			want:    []string{"a=2@example.com", "b=1@example.com", "c=1@example.org", "d=1@example.com", "e=1@example.net"},
+			added:   []string{"d=1@example.com", "e=1@example.net"},
+			updated: []string{"a=2@example.com"},
+			removed: []string{},
+		},
+		{
+			mode:    MergeKeepNewer,
+			scope:   scope,
+			want:    []string{"a=1@example.com", "b=1@example.com", "c=1@example.org", "d=1@example.com"},
+			added:   []string{"d=1@example.com"},
+			updated: []string{},
+			removed: []string{},
+		},
+	}
+	for _, test := range tests {
+		got, diff := MergeCookies(dst, src, test.scope, test.mode)
+		if names := cookieNames(got); !reflect.DeepEqual(names, test.want) {
+			t.Errorf("%s: %#v != %#v", test.mode, names, test.want)
+		}
+		if names := cookieNames(diff.Added); !reflect.DeepEqual(names, test.added) {
+			t.Errorf("%s: added: %#v != %#v", test.mode, names, test.added)
+		}
+		if names := cookieNames(diff.Updated); !reflect.DeepEqual(names, test.updated) {
+			t.Errorf("%s: updated: %#v != %#v", test.mode, names, test.updated)
+		}
+		if names := cookieNames(diff.Removed); !reflect.DeepEqual(names, test.removed) {
+			t.Errorf("%s: removed: %#v != %#v", test.mode, names, test.removed)
+		}
+	}
+	if dst[0].Value != "1" || len(dst) != 3 {
+		t.Fatalf("dst is modified: %v", cookieNames(dst))
+	}
+}
+
+func TestCookieScope(t *testing.T) {
+	t.Parallel()
+	secure := testCookie("s", "1", ".example.com", -1)
+	secure.Secure = true
+	tests := []struct {
+		scope  *CookieScope
+		cookie *network.Cookie
+		want   bool
+	}{
+		{scope: nil, cookie: testCookie("a", "1", "example.com", -1), want: true},
+		{scope: &CookieScope{}, cookie: testCookie("a", "1", "example.com", -1), want: true},
+		{scope: &CookieScope{Domains: []string{"example.com"}}, cookie: testCookie("a", "1", ".sub.example.com", -1), want: true},
+		{scope: &CookieScope{Domains: []string{"example.com"}}, cookie: testCookie("a", "1", "badexample.com", -1), want: false},
+		{scope: &CookieScope{URLs: []string{"https://www.example.com/"}}, cookie: secure, want: true},
+		{scope: &CookieScope{URLs: []string{"http://www.example.com/"}}, cookie: secure, want: false},
+		{scope: &CookieScope{URLs: []string{"https://example.org/", "https://example.com/"}}, cookie: testCookie("a", "1", "example.com", -1), want: true},
+		{scope: &CookieScope{URLs: []string{"https://example.com/"}, Domains: []string{"example.org"}}, cookie: testCookie("a", "1", "example.com", -1), want: false},
+	}
+	for i, test := range tests {
+		if got := test.scope.Contains(test.cookie); got != test.want {
+			t.Errorf("%d: %#v != %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestMergeCookiesTo(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocateSeparate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	store := &MemoryCookieStore{}
+	other := testCookie("other-account", "1", "example.com", -1)
+	if err := store.Save(context.Background(), []*network.Cookie{other}); err != nil {
+		t.Fatal(err)
+	}
+	var diff CookieDiff
+	scope := &CookieScope{URLs: []string{endpoint + "/cookies"}}
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(endpoint + "/cookies"),
+		MergeCookiesTo(store, scope, MergeReplace, &diff),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"other-account=1@example.com", "test-cookie-01=testval01@127.0.0.1", "test-cookie-02=testval02@127.0.0.1"}
+	if names := cookieNames(got); !reflect.DeepEqual(names, want) {
+		t.Fatalf("%#v != %#v", names, want)
+	}
+	if len(diff.Added) != 2 || len(diff.Updated) != 0 || len(diff.Removed) != 0 {
+		t.Fatalf("invalid diff: %+v", diff)
+	}
+}
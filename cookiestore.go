@@ -0,0 +1,261 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// CookieStore loads and saves cookies.
+type CookieStore interface {
+	// Load returns the saved cookies. It returns no cookies if nothing is saved.
+	Load(ctx context.Context) ([]*network.Cookie, error)
+	// Save replaces the saved cookies with cookies.
+	Save(ctx context.Context, cookies []*network.Cookie) error
+}
+
+// SaveCookiesTo is an action that saves cookies to store.
+func SaveCookiesTo(store CookieStore, maps ...func(*network.Cookie)) chromedp.Action {
+	mapFunc := func(c *network.Cookie) {
+		for _, m := range maps {
+			m(c)
+		}
+	}
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		ctx, span := startSpan(ctx, "SaveCookies")
+		defer func() { endSpan(span, err) }()
+		if s, ok := store.(*FileCookieStore); ok {
+			span.SetAttributes(AttrFilename.String(s.Filename()))
+		}
+
+		cookies, err := network.GetAllCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("SaveCookies: cookie(s)=%d\n", len(cookies))
+		span.SetAttributes(AttrCookies.Int(len(cookies)))
+		for _, c := range cookies {
+			mapFunc(c)
+		}
+		return store.Save(ctx, cookies)
+	})
+}
+
+// RestoreCookiesFrom is an action that restores cookies from store.
+//
+// filters are applied in order and can modify the cookie to restore.
+// Session cookies are restored as session cookies.
+func RestoreCookiesFrom(store CookieStore, filters ...func(*network.Cookie) bool) chromedp.Action {
+	filter := func(c *network.Cookie) bool {
+		for _, f := range filters {
+			if !f(c) {
+				return false
+			}
+		}
+		return true
+	}
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		ctx, span := startSpan(ctx, "RestoreCookies")
+		defer func() { endSpan(span, err) }()
+		if s, ok := store.(*FileCookieStore); ok {
+			span.SetAttributes(AttrFilename.String(s.Filename()))
+		}
+
+		loaded, err := store.Load(ctx)
+		if err != nil {
+			return err
+		}
+		cookies := make([]*network.Cookie, 0, len(loaded))
+		for _, c := range loaded {
+			if filter(c) {
+				cookies = append(cookies, c)
+			}
+		}
+		log.Printf("RestoreCookies: cookie(s)=%d\n", len(cookies))
+		span.SetAttributes(AttrCookies.Int(len(cookies)))
+
+		if err := setCookies(ctx, cookies); err != nil {
+			return err
+		}
+		metrics(ctx).CookiesRestored(len(cookies))
+		return nil
+	})
+}
+
+// setCookies adds cookies to browser.
+func setCookies(ctx context.Context, cookies []*network.Cookie) error {
+	for _, c := range cookies {
+		p := network.SetCookie(c.Name, c.Value).
+			WithDomain(c.Domain).
+			WithPath(c.Path).
+			WithSecure(c.Secure).
+			WithHTTPOnly(c.HTTPOnly).
+			WithSameSite(c.SameSite).
+			WithPriority(c.Priority)
+		if expires := cookieExpires(c); expires != nil {
+			p = p.WithExpires(expires)
+		}
+		success, err := p.Do(ctx)
+		if err != nil {
+			return err
+		}
+		if !success {
+			return &CookieError{Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path}
+		}
+	}
+	return nil
+}
+
+// FileCookieStore is a CookieStore which stores cookies as json lines file.
+//
+// The file is written atomically with mode 0600.
+type FileCookieStore struct {
+	filename interface{}
+}
+
+// NewFileCookieStore returns a new FileCookieStore.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func NewFileCookieStore(filename interface{}) *FileCookieStore {
+	return &FileCookieStore{filename: filename}
+}
+
+// Filename returns the name of the file.
+func (s *FileCookieStore) Filename() string {
+	return toString(s.filename)
+}
+
+// Load implements CookieStore.
+func (s *FileCookieStore) Load(ctx context.Context) ([]*network.Cookie, error) {
+	f, err := os.Open(s.Filename())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return readCookies(f)
+}
+
+// Save implements CookieStore.
+func (s *FileCookieStore) Save(ctx context.Context, cookies []*network.Cookie) error {
+	return writeFileAtomic(s.Filename(), 0600, func(w io.Writer) error {
+		return writeCookies(w, cookies)
+	})
+}
+
+// IOCookieStore is a CookieStore which reads cookies from io.Reader and writes them to io.Writer as json lines.
+type IOCookieStore struct {
+	r io.Reader
+	w io.Writer
+}
+
+// NewIOCookieStore returns a new IOCookieStore.
+//
+// r or w can be nil if the store is used only for saving or loading.
+func NewIOCookieStore(r io.Reader, w io.Writer) *IOCookieStore {
+	return &IOCookieStore{r: r, w: w}
+}
+
+// Load implements CookieStore.
+func (s *IOCookieStore) Load(ctx context.Context) ([]*network.Cookie, error) {
+	if s.r == nil {
+		return nil, nil
+	}
+	return readCookies(s.r)
+}
+
+// Save implements CookieStore.
+func (s *IOCookieStore) Save(ctx context.Context, cookies []*network.Cookie) error {
+	if s.w == nil {
+		return errors.New("cookie store has no writer")
+	}
+	return writeCookies(s.w, cookies)
+}
+
+// MemoryCookieStore is a CookieStore which keeps cookies in memory.
+//
+// The zero value is an empty store.
+type MemoryCookieStore struct {
+	mu      sync.Mutex
+	cookies []*network.Cookie
+}
+
+// Load implements CookieStore.
+func (s *MemoryCookieStore) Load(ctx context.Context) ([]*network.Cookie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyCookies(s.cookies), nil
+}
+
+// Save implements CookieStore.
+func (s *MemoryCookieStore) Save(ctx context.Context, cookies []*network.Cookie) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cookies = copyCookies(cookies)
+	return nil
+}
+
+// DirCookieStore stores cookies of profiles as json lines files in a directory.
+type DirCookieStore struct {
+	dir string
+}
+
+// NewDirCookieStore returns a new DirCookieStore.
+func NewDirCookieStore(dir string) *DirCookieStore {
+	return &DirCookieStore{dir: dir}
+}
+
+// Profile returns the CookieStore of the profile.
+func (s *DirCookieStore) Profile(name string) (*FileCookieStore, error) {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return nil, fmt.Errorf("invalid profile name %q", name)
+	}
+	return NewFileCookieStore(filepath.Join(s.dir, name+".jsonl")), nil
+}
+
+func readCookies(r io.Reader) ([]*network.Cookie, error) {
+	d := json.NewDecoder(r)
+	cookies := make([]*network.Cookie, 0)
+	for {
+		var c network.Cookie
+		if err := d.Decode(&c); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		cookies = append(cookies, &c)
+	}
+	return cookies, nil
+}
+
+func writeCookies(w io.Writer, cookies []*network.Cookie) error {
+	e := json.NewEncoder(w)
+	for _, c := range cookies {
+		if err := e.Encode(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyCookies(cookies []*network.Cookie) []*network.Cookie {
+	res := make([]*network.Cookie, len(cookies))
+	for i, c := range cookies {
+		cc := *c
+		res[i] = &cc
+	}
+	return res
+}
@@ -0,0 +1,133 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func testCookies() []*network.Cookie {
+	return []*network.Cookie{
+		{
+			Name:     "test-cookie-01",
+			Value:    "testval01",
+			Domain:   "127.0.0.1",
+			Path:     "/",
+			Expires:  -1,
+			Session:  true,
+			Priority: network.CookiePriorityMedium,
+		},
+		{
+			Name:     "test-cookie-02",
+			Value:    "testval02",
+			Domain:   "example.com",
+			Path:     "/",
+			Expires:  1893456000,
+			HTTPOnly: true,
+			Secure:   true,
+			Priority: network.CookiePriorityMedium,
+		},
+	}
+}
+
+func testCookieStore(t *testing.T, store CookieStore) {
+	t.Helper()
+	ctx := context.Background()
+	want := testCookies()
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("\nwant: %+v\n got: %+v", want, got)
+	}
+}
+
+func TestFileCookieStore(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileCookieStore(filepath.Join(dir, "cookies.jsonl"))
+	got, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no cookies, got %d", len(got))
+	}
+	testCookieStore(t, store)
+
+	// saving fewer cookies must truncate the file
+	if err := store.Save(context.Background(), testCookies()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	got, err = store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 cookie, got %d", len(got))
+	}
+}
+
+func TestIOCookieStore(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	testCookieStore(t, NewIOCookieStore(&buf, &buf))
+
+	if err := NewIOCookieStore(nil, nil).Save(context.Background(), testCookies()); err == nil {
+		t.Fatal("expected error without writer")
+	}
+}
+
+func TestMemoryCookieStore(t *testing.T) {
+	t.Parallel()
+	store := &MemoryCookieStore{}
+	testCookieStore(t, store)
+
+	// modifying loaded cookies must not change the store
+	got, _ := store.Load(context.Background())
+	got[0].Value = "modified"
+	got, _ = store.Load(context.Background())
+	if got[0].Value != "testval01" {
+		t.Fatal("expected stored cookies not to be modified")
+	}
+}
+
+func TestDirCookieStore(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := NewDirCookieStore(dir)
+	store, err := d.Profile("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "alice.jsonl"); store.Filename() != want {
+		t.Fatalf("%#v != %#v", store.Filename(), want)
+	}
+	testCookieStore(t, store)
+
+	for _, name := range []string{"", ".", "..", "../alice", "a/b"} {
+		if _, err := d.Profile(name); err == nil {
+			t.Fatalf("expected error for profile name %q", name)
+		}
+	}
+}
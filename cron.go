@@ -0,0 +1,237 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the activation times of a scheduled job.
+type Schedule interface {
+	// Next returns the first activation time after t, or the zero time if there is none.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a Schedule which activates every d.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("non-positive interval for Every")
+	}
+	return every(d)
+}
+
+type every time.Duration
+
+func (d every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// CronSchedule is a Schedule of a cron expression.
+type CronSchedule struct {
+	seconds, minutes, hours, days, months, weekdays uint64
+	// anyDay and anyWeekday are true if the field is "*", which changes how days and weekdays are combined.
+	anyDay, anyWeekday bool
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronSecond  = cronField{min: 0, max: 59}
+	cronMinute  = cronField{min: 0, max: 59}
+	cronHour    = cronField{min: 0, max: 23}
+	cronDay     = cronField{min: 1, max: 31}
+	cronMonth   = cronField{min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	cronWeekday = cronField{min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression.
+//
+// The expression has 5 fields (minute, hour, day of month, month and day of week)
+// or 6 fields with seconds at the beginning. Fields support "*", lists, ranges, steps
+// and the names of months and days of week. The descriptors @yearly, @annually, @monthly,
+// @weekly, @daily, @midnight, @hourly and "@every <duration>" are also supported.
+// The activation times are in the location of the time passed to Next.
+func ParseCron(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid cron expression %q: non-positive interval", expr)
+		}
+		return every(d), nil
+	}
+	if s, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = s
+	}
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 or 6 fields", expr)
+	}
+	var s CronSchedule
+	var err error
+	parse := func(i int, f cronField) uint64 {
+		if err != nil {
+			return 0
+		}
+		var bits uint64
+		bits, err = f.parse(fields[i])
+		if err != nil {
+			err = fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		return bits
+	}
+	s.seconds = parse(0, cronSecond)
+	s.minutes = parse(1, cronMinute)
+	s.hours = parse(2, cronHour)
+	s.days = parse(3, cronDay)
+	s.months = parse(4, cronMonth)
+	s.weekdays = parse(5, cronWeekday)
+	if err != nil {
+		return nil, err
+	}
+	// 7 is also Sunday
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	s.anyDay = fields[3] == "*" || fields[3] == "?"
+	s.anyWeekday = fields[5] == "*" || fields[5] == "?"
+	return &s, nil
+}
+
+// parse parses the field expression into a bit set.
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+		var lo, hi int
+		switch {
+		case rng == "*" || rng == "?":
+			lo, hi = f.min, f.max
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err error
+			if lo, err = f.value(rng[:i]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(rng[i+1:]); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if lo, err = f.value(rng); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if step > 1 {
+				hi = f.max
+			}
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	}
+	// both are restricted, so either matches like cron
+	return day || weekday
+}
+
+// Next implements Schedule.
+//
+// Times which do not exist on the spring-forward day of daylight saving time are skipped.
+// Jobs at specific hours run once in the repeated hour of the fall-back day, and jobs at every hour run on both.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, mo, d := t.Date()
+		h, mi, sec := t.Clock()
+		prev := t
+		switch {
+		case s.months&(1<<uint(mo)) == 0:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, loc)
+		// step by absolute time below, since wall clock times may not exist or repeat around DST transitions
+		case s.hours&(1<<uint(h)) == 0:
+			t = t.Add(-time.Duration(mi)*time.Minute - time.Duration(sec)*time.Second + time.Hour)
+		case s.minutes&(1<<uint(mi)) == 0:
+			t = t.Add(-time.Duration(sec)*time.Second + time.Minute)
+		case s.seconds&(1<<uint(sec)) == 0:
+			t = t.Add(time.Second)
+		case s.hours != cronAllHours && repeatedHour(t):
+			t = t.Add(-time.Duration(mi)*time.Minute - time.Duration(sec)*time.Second + time.Hour)
+		default:
+			return t
+		}
+		if !t.After(prev) {
+			// the wall clock time was normalized backward by a DST transition
+			t = prev.Add(time.Second)
+		}
+	}
+	return time.Time{}
+}
+
+const cronAllHours = 1<<24 - 1
+
+// repeatedHour reports whether t is in the second occurrence of the repeated hour of a DST transition.
+func repeatedHour(t time.Time) bool {
+	u := t.Add(-time.Hour)
+	return u.Hour() == t.Hour() && u.Day() == t.Day()
+}
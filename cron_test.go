@@ -0,0 +1,227 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2020, 1, 1, 10, 30, 15, 0, time.UTC) // Wednesday
+	tests := map[string]struct {
+		expr string
+		want []time.Time
+	}{
+		"every minute": {
+			expr: "* * * * *",
+			want: []time.Time{
+				time.Date(2020, 1, 1, 10, 31, 0, 0, time.UTC),
+				time.Date(2020, 1, 1, 10, 32, 0, 0, time.UTC),
+			},
+		},
+		"step": {
+			expr: "*/20 * * * *",
+			want: []time.Time{
+				time.Date(2020, 1, 1, 10, 40, 0, 0, time.UTC),
+				time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC),
+			},
+		},
+		"list and range": {
+			expr: "0 9-10,17 * * *",
+			want: []time.Time{
+				time.Date(2020, 1, 1, 17, 0, 0, 0, time.UTC),
+				time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC),
+				time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"seconds": {
+			expr: "30 30 10 * * *",
+			want: []time.Time{
+				time.Date(2020, 1, 1, 10, 30, 30, 0, time.UTC),
+				time.Date(2020, 1, 2, 10, 30, 30, 0, time.UTC),
+			},
+		},
+		"weekday names": {
+			expr: "0 10 * * mon-fri",
+			want: []time.Time{
+				time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC),
+				time.Date(2020, 1, 3, 10, 0, 0, 0, time.UTC),
+				time.Date(2020, 1, 6, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"sunday as 7": {
+			expr: "0 0 * * 7",
+			want: []time.Time{
+				time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"day or weekday": {
+			expr: "0 0 10 * sat",
+			want: []time.Time{
+				time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, 1, 11, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"month names": {
+			expr: "0 0 1 feb,Dec *",
+			want: []time.Time{
+				time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"leap day": {
+			expr: "0 0 29 2 *",
+			want: []time.Time{
+				time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"hourly": {
+			expr: "@hourly",
+			want: []time.Time{
+				time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC),
+			},
+		},
+		"every": {
+			expr: "@every 90s",
+			want: []time.Time{
+				time.Date(2020, 1, 1, 10, 31, 45, 0, time.UTC),
+				time.Date(2020, 1, 1, 10, 33, 15, 0, time.UTC),
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := from
+			for _, want := range tt.want {
+				at = s.Next(at)
+				if !at.Equal(want) {
+					t.Fatalf("%v != %v", at, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCronError(t *testing.T) {
+	t.Parallel()
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@every 0s",
+		"@every foo",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("expected error expr=%q", expr)
+		}
+	}
+}
+
+func TestCronScheduleNever(t *testing.T) {
+	t.Parallel()
+	s, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Fatalf("%v != zero time", got)
+	}
+}
+
+func TestCronScheduleDST(t *testing.T) {
+	t.Parallel()
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "daily on spring forward",
+			expr: "0 0 9 * * *",
+			from: time.Date(2020, 3, 8, 0, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2020, 3, 8, 9, 0, 0, 0, ny),
+			},
+		},
+		{
+			name: "skipped hour on spring forward",
+			expr: "30 2 * * *",
+			from: time.Date(2020, 3, 8, 0, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2020, 3, 9, 2, 30, 0, 0, ny),
+			},
+		},
+		{
+			name: "every hour on spring forward",
+			expr: "0 * * * *",
+			from: time.Date(2020, 3, 8, 0, 30, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2020, 3, 8, 1, 0, 0, 0, ny),
+				time.Date(2020, 3, 8, 3, 0, 0, 0, ny),
+			},
+		},
+		{
+			name: "specific hour on fall back",
+			expr: "30 1 * * *",
+			from: time.Date(2020, 11, 1, 0, 0, 0, 0, ny),
+			want: []time.Time{
+				time.Date(2020, 11, 1, 5, 30, 0, 0, time.UTC),
+				time.Date(2020, 11, 2, 1, 30, 0, 0, ny),
+			},
+		},
+		{
+			name: "every minute on fall back",
+			expr: "*/30 * * * *",
+			from: time.Date(2020, 11, 1, 5, 0, 0, 0, time.UTC).In(ny),
+			want: []time.Time{
+				time.Date(2020, 11, 1, 5, 30, 0, 0, time.UTC),
+				time.Date(2020, 11, 1, 6, 0, 0, 0, time.UTC),
+				time.Date(2020, 11, 1, 6, 30, 0, 0, time.UTC),
+				time.Date(2020, 11, 1, 7, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := tt.from
+			for _, want := range tt.want {
+				done := make(chan time.Time, 1)
+				go func(at time.Time) { done <- s.Next(at) }(at)
+				select {
+				case at = <-done:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Next did not return from=%v", at)
+				}
+				if !at.Equal(want) {
+					t.Fatalf("%v != %v", at, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+var (
+	// ErrCanceledByUser is an error because of canceled by user.
+	ErrCanceledByUser = errors.New("canceled by user")
+)
+
+// StatusError is an error because of an unexpected response status.
+type StatusError struct {
+	URL     string
+	Status  int64
+	Headers network.Headers
+}
+
+func (e *StatusError) Error() string {
+	text := strings.ReplaceAll(http.StatusText(int(e.Status)), " ", "")
+	if text == "" {
+		text = fmt.Sprint(e.Status)
+	}
+	return fmt.Sprintf("status=%s url=%s", text, e.URL)
+}
+
+// TimeoutError is an error because of timeout exceeded.
+//
+// Err is the last failure observed before the timeout, if any.
+type TimeoutError struct {
+	Action  string
+	URL     string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	msg := fmt.Sprintf("%s: timeout exceeded timeout=%s", e.Action, e.Timeout)
+	if e.URL != "" {
+		msg += " url=" + e.URL
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+type timeoutErrorsKey struct{}
+
+// WithTimeoutErrors returns a copy of ctx in which WaitResponse and WaitLoaded return *TimeoutError if timeout exceeded.
+//
+// They return nil if timeout exceeded by default, and the actions following them run on the page as it is.
+func WithTimeoutErrors(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timeoutErrorsKey{}, true)
+}
+
+// timeoutError returns err if WithTimeoutErrors is set to ctx, and nil otherwise.
+func timeoutError(ctx context.Context, err *TimeoutError) error {
+	if enabled, _ := ctx.Value(timeoutErrorsKey{}).(bool); enabled {
+		return err
+	}
+	return nil
+}
+
+// NetworkError is an error because of a failed request.
+type NetworkError struct {
+	URL           string
+	ErrorText     string
+	Canceled      bool
+	BlockedReason network.BlockedReason
+}
+
+func (e *NetworkError) Error() string {
+	msg := fmt.Sprintf("error=%s url=%s", e.ErrorText, e.URL)
+	if e.BlockedReason != "" {
+		msg += fmt.Sprintf(" blocked=%s", e.BlockedReason)
+	}
+	return msg
+}
+
+// CookieError is an error because of a cookie which could not be set.
+type CookieError struct {
+	Name   string
+	Value  string
+	Domain string
+	Path   string
+}
+
+func (e *CookieError) Error() string {
+	return fmt.Sprintf("could not set cookie %s to %s", e.Name, e.Value)
+}
+
+// ProfileLockedError is an error because of a profile used by another job.
+//
+// PID and Since are the process id and the time which locked the profile if they are known.
+type ProfileLockedError struct {
+	Name  string
+	PID   int
+	Since time.Time
+}
+
+func (e *ProfileLockedError) Error() string {
+	msg := fmt.Sprintf("profile %s is locked", e.Name)
+	if e.PID != 0 {
+		msg += fmt.Sprintf(" pid=%d", e.PID)
+	}
+	if !e.Since.IsZero() {
+		msg += " since=" + e.Since.Format(time.RFC3339)
+	}
+	return msg
+}
+
+// LoginError is an error because of login which could not be established.
+//
+// Err is the last error of the login actions, if any.
+type LoginError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *LoginError) Error() string {
+	msg := fmt.Sprintf("could not log in after %d attempt(s)", e.Attempts)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *LoginError) Unwrap() error {
+	return e.Err
+}
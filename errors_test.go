@@ -0,0 +1,159 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "status error",
+			err:  &StatusError{URL: "https://example.com", Status: http.StatusBadRequest},
+			want: "status=BadRequest url=https://example.com",
+		},
+		{
+			name: "unknown status error",
+			err:  &StatusError{URL: "https://example.com", Status: 599},
+			want: "status=599 url=https://example.com",
+		},
+		{
+			name: "timeout error",
+			err:  &TimeoutError{Action: "WaitLoaded", Timeout: time.Second},
+			want: "WaitLoaded: timeout exceeded timeout=1s",
+		},
+		{
+			name: "timeout error with last failure",
+			err: &TimeoutError{
+				Action:  "WaitResponse",
+				URL:     "https://example.com",
+				Timeout: time.Second,
+				Err:     &StatusError{URL: "https://example.com", Status: http.StatusServiceUnavailable},
+			},
+			want: "WaitResponse: timeout exceeded timeout=1s url=https://example.com: status=ServiceUnavailable url=https://example.com",
+		},
+		{
+			name: "network error",
+			err:  &NetworkError{URL: "https://example.com", ErrorText: "net::ERR_FAILED"},
+			want: "error=net::ERR_FAILED url=https://example.com",
+		},
+		{
+			name: "blocked network error",
+			err: &NetworkError{
+				URL:           "https://example.com",
+				ErrorText:     "net::ERR_BLOCKED_BY_CLIENT",
+				BlockedReason: network.BlockedReasonInspector,
+			},
+			want: "error=net::ERR_BLOCKED_BY_CLIENT url=https://example.com blocked=inspector",
+		},
+		{
+			name: "cookie error",
+			err:  &CookieError{Name: "name", Value: "value"},
+			want: "could not set cookie name to value",
+		},
+		{
+			name: "login error",
+			err:  &LoginError{Attempts: 3, Err: &StatusError{URL: "https://example.com", Status: http.StatusUnauthorized}},
+			want: "could not log in after 3 attempt(s): status=Unauthorized url=https://example.com",
+		},
+		{
+			name: "profile locked error",
+			err:  &ProfileLockedError{Name: "account", PID: 42, Since: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+			want: "profile account is locked pid=42 since=2020-01-02T03:04:05Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeoutErrorUnwrap(t *testing.T) {
+	t.Parallel()
+	var err error = &TimeoutError{
+		Action: "WaitResponse",
+		Err:    &NetworkError{ErrorText: "net::ERR_FAILED"},
+	}
+	var nerr *NetworkError
+	if !errors.As(err, &nerr) {
+		t.Fatalf("expected %v to wrap *NetworkError", err)
+	}
+	if nerr.ErrorText != "net::ERR_FAILED" {
+		t.Fatalf("unexpected error text: %s", nerr.ErrorText)
+	}
+}
+
+func TestTimeoutErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		action chromedp.Action
+		strict bool
+		want   string
+	}{
+		{name: "WaitLoaded", action: WaitLoaded(time.Minute), want: ""},
+		{name: "WaitLoaded with timeout errors", action: WaitLoaded(time.Minute), strict: true, want: "WaitLoaded: timeout exceeded timeout=1m0s"},
+		{name: "WaitResponse", action: WaitResponse("https://example.com", time.Minute), want: ""},
+		{name: "WaitResponse with timeout errors", action: WaitResponse("https://example.com", time.Minute), strict: true, want: "WaitResponse: timeout exceeded timeout=1m0s url=https://example.com"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// no browser is needed because no event is received
+			ctx, cancel := chromedp.NewContext(context.Background())
+			defer cancel()
+			c := NewFakeClock(time.Now())
+			ctx = WithClock(ctx, c)
+			if tt.strict {
+				ctx = WithTimeoutErrors(ctx)
+			}
+			ch := make(chan error, 1)
+			go func() { ch <- tt.action.Do(ctx) }()
+			c.BlockUntil(1)
+			c.Advance(time.Minute)
+			got := ""
+			if err := <-ch; err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNavigateStatusError(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	tasks := chromedp.Tasks{
+		network.Enable(),
+		EnableLifeCycleEvents(),
+		Navigate(endpoint+"/gone", 5*time.Second),
+	}
+	err := chromedp.Run(ctx, tasks)
+	var serr *StatusError
+	if !errors.As(err, &serr) {
+		t.Fatalf("expected *StatusError, got %#v", err)
+	}
+	if serr.Status != http.StatusGone {
+		t.Fatalf("expected status to be %d, got %d", http.StatusGone, serr.Status)
+	}
+}
@@ -0,0 +1,153 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// HandoffState is the state of a manual handoff.
+type HandoffState string
+
+const (
+	// HandoffDetected is the state when the challenge is detected.
+	HandoffDetected HandoffState = "detected"
+	// HandoffWaiting is the state while waiting for the operator.
+	HandoffWaiting HandoffState = "waiting"
+	// HandoffCompleted is the state when the challenge is completed.
+	HandoffCompleted HandoffState = "completed"
+	// HandoffCanceled is the state when the operator canceled the handoff.
+	HandoffCanceled HandoffState = "canceled"
+)
+
+// Handoff hands a challenge such as captcha or 2FA over to an operator.
+type Handoff struct {
+	// Selector detects the challenge. The handoff is skipped if no element matches it.
+	Selector string
+	// Message is shown to the operator.
+	Message string
+	// Provider asks the operator. The operator is asked on the terminal if it is nil.
+	Provider ApprovalProvider
+	// Screenshot is the file to save the page when the challenge is detected. It is not saved if nil.
+	// It can be specified by string, string pointer or fmt.Stringer.
+	Screenshot interface{}
+	// Success reports whether the challenge is completed.
+	// The challenge is completed when no element matches Selector if it is nil.
+	Success func(ctx context.Context) (bool, error)
+	// PollInterval is the interval to check Success while waiting for the operator. The default is 1 second.
+	PollInterval time.Duration
+	// OnStateChange is called when the state changes. It can be nil.
+	OnStateChange func(state HandoffState)
+}
+
+func (h *Handoff) setState(state HandoffState) {
+	log.Printf("Handoff: state=%s selector=%s\n", state, h.Selector)
+	if h.OnStateChange != nil {
+		h.OnStateChange(state)
+	}
+}
+
+func (h *Handoff) success(ctx context.Context) (bool, error) {
+	if h.Success != nil {
+		return h.Success(ctx)
+	}
+	found, err := elementExists(ctx, h.Selector)
+	return !found, err
+}
+
+// HandleChallenge is an action that waits for the operator to complete the challenge detected by h.
+//
+// It asks the operator until Success holds, and resumes as soon as Success holds even if the operator does not respond.
+// It returns ErrCanceledByUser if the operator rejects the handoff.
+func HandleChallenge(h *Handoff) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		found, err := elementExists(ctx, h.Selector)
+		if err != nil || !found {
+			return err
+		}
+		ctx, span := startSpan(ctx, "HandleChallenge")
+		defer func() { endSpan(span, err) }()
+		h.setState(HandoffDetected)
+
+		if h.Screenshot != nil {
+			name := toString(h.Screenshot)
+			b, err := page.CaptureScreenshot().Do(ctx)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(name, b, 0600); err != nil {
+				return err
+			}
+			span.SetAttributes(AttrFilename.String(name))
+		}
+
+		provider := h.Provider
+		if provider == nil {
+			provider = TerminalApproval(os.Stdin)
+		}
+		interval := h.PollInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		ticker := clock(ctx).NewTicker(interval)
+		defer ticker.Stop()
+
+		message := h.Message
+		for {
+			h.setState(HandoffWaiting)
+			actx, cancel := context.WithCancel(ctx)
+			done := make(chan error, 1)
+			go func() {
+				done <- WaitApproval(provider, message, nil).Do(actx)
+			}()
+			completed, err := h.wait(ctx, ticker.C(), done)
+			cancel()
+			if err != nil {
+				if err == ErrCanceledByUser {
+					h.setState(HandoffCanceled)
+				}
+				return err
+			}
+			if completed {
+				h.setState(HandoffCompleted)
+				return nil
+			}
+			message = fmt.Sprintf("%s (not completed yet)", h.Message)
+		}
+	})
+}
+
+// wait waits until the operator responds or Success holds and reports whether the challenge is completed.
+func (h *Handoff) wait(ctx context.Context, tick <-chan time.Time, done <-chan error) (bool, error) {
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return false, err
+			}
+			return h.success(ctx)
+		case <-tick:
+			ok, err := h.success(ctx)
+			if err != nil || ok {
+				return ok, err
+			}
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// elementExists reports whether the document has an element matching sel.
+func elementExists(ctx context.Context, sel string) (bool, error) {
+	var found bool
+	if err := chromedp.Evaluate(fmt.Sprintf("document.querySelector(%q) !== null", sel), &found).Do(ctx); err != nil {
+		return false, err
+	}
+	return found, nil
+}
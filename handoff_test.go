@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestHandoffWait(t *testing.T) {
+	t.Parallel()
+	errProbe := errors.New("probe failed")
+	tests := []struct {
+		name      string
+		successes []bool
+		probeErr  error
+		responded error
+		respond   bool
+		want      bool
+		wantErr   error
+	}{
+		{name: "completed by operator", successes: []bool{true}, respond: true, want: true},
+		{name: "not completed", successes: []bool{false}, respond: true, want: false},
+		{name: "completed while waiting", successes: []bool{false, true}, want: true},
+		{name: "rejected", respond: true, responded: ErrCanceledByUser, wantErr: ErrCanceledByUser},
+		{name: "success error", probeErr: errProbe, wantErr: errProbe},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := &Handoff{Success: func(ctx context.Context) (bool, error) {
+				if tt.probeErr != nil {
+					return false, tt.probeErr
+				}
+				ok := tt.successes[calls]
+				calls++
+				return ok, nil
+			}}
+			done := make(chan error, 1)
+			if tt.respond {
+				done <- tt.responded
+			}
+			tick := make(chan time.Time, len(tt.successes)+1)
+			if !tt.respond {
+				for i := 0; i < cap(tick); i++ {
+					tick <- time.Now()
+				}
+			}
+			got, err := h.wait(context.Background(), tick, done)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%#v != %#v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleChallenge(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	var states []HandoffState
+	provider := ApprovalProviderFunc(func(ctx context.Context, req *ApprovalRequest) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	h := &Handoff{
+		Selector:      "#captcha",
+		Message:       "solve the captcha",
+		Provider:      provider,
+		PollInterval:  100 * time.Millisecond,
+		OnStateChange: func(state HandoffState) { states = append(states, state) },
+	}
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(endpoint + "/challenge"),
+		HandleChallenge(h),
+		chromedp.Navigate(endpoint + "/index.html"),
+		HandleChallenge(h),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	want := []HandoffState{HandoffDetected, HandoffWaiting, HandoffCompleted}
+	if len(states) != len(want) {
+		t.Fatalf("%#v != %#v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("%#v != %#v", states, want)
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package helper
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const modulePath = "github.com/go-oss/chromedp-helper"
+
+// HAR is a HTTP Archive 1.2 document.
+//
+// See: http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log *HARLog `json:"log"`
+}
+
+// HARLog is the root of the exported data.
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator *HARCreator `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+	Comment string      `json:"comment,omitempty"`
+}
+
+// HARCreator is the application which created the log.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is an exported HTTP request.
+type HAREntry struct {
+	StartedDateTime time.Time    `json:"startedDateTime"`
+	Time            float64      `json:"time"`
+	Request         *HARRequest  `json:"request"`
+	Response        *HARResponse `json:"response"`
+	Cache           struct{}     `json:"cache"`
+	Timings         *HARTimings  `json:"timings"`
+	ServerIPAddress string       `json:"serverIPAddress,omitempty"`
+	Comment         string       `json:"comment,omitempty"`
+}
+
+// HARRequest is the detail of a request.
+type HARRequest struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARCookie    `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	QueryString []*HARNameValue `json:"queryString"`
+	PostData    *HARPostData    `json:"postData,omitempty"`
+	HeadersSize int64           `json:"headersSize"`
+	BodySize    int64           `json:"bodySize"`
+}
+
+// HARResponse is the detail of a response.
+type HARResponse struct {
+	Status      int64           `json:"status"`
+	StatusText  string          `json:"statusText"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARCookie    `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	Content     *HARContent     `json:"content"`
+	RedirectURL string          `json:"redirectURL"`
+	HeadersSize int64           `json:"headersSize"`
+	BodySize    int64           `json:"bodySize"`
+}
+
+// HARCookie is a cookie used in a request or a response.
+type HARCookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+// HARNameValue is a header or a query parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the posted data of a request.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the content of a response.
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Body returns the decoded content text.
+func (c *HARContent) Body() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+// HARTimings is the timings of a request in milliseconds. -1 means not applicable.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARRecorder records network events as HAR.
+//
+// network.Enable must be run before recording.
+type HARRecorder struct {
+	// Bodies enables recording response bodies via network.GetResponseBody.
+	Bodies bool
+
+	mu      sync.Mutex
+	entries []*HAREntry
+	pending map[network.RequestID]*harPending
+	bodies  sync.WaitGroup
+	cancel  context.CancelFunc
+	// stopped is set by Stop, and session is incremented by Start,
+	// so that the events delivered to the listeners after Stop are ignored
+	// because chromedp removes canceled listeners asynchronously.
+	stopped bool
+	session int
+}
+
+type harPending struct {
+	entry  *HAREntry
+	start  time.Time
+	timing *network.ResourceTiming
+}
+
+// Start is an action that starts recording.
+func (r *HARRecorder) Start() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.cancel != nil {
+			return nil
+		}
+		if r.pending == nil {
+			r.pending = make(map[network.RequestID]*harPending)
+		}
+		lctx, cancel := context.WithCancel(ctx)
+		r.cancel = cancel
+		r.stopped = false
+		r.session++
+		session := r.session
+		chromedp.ListenTarget(lctx, func(ev interface{}) {
+			r.handle(ctx, session, ev)
+		})
+		log.Println("HARRecorder: start")
+		return nil
+	})
+}
+
+// Stop is an action that stops recording and waits for the response bodies being fetched.
+func (r *HARRecorder) Stop() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		r.mu.Lock()
+		if r.cancel != nil {
+			r.cancel()
+			r.cancel = nil
+		}
+		r.stopped = true
+		r.mu.Unlock()
+
+		done := make(chan struct{})
+		go func() {
+			r.bodies.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		log.Printf("HARRecorder: stop entries=%d\n", len(r.HAR().Log.Entries))
+		return nil
+	})
+}
+
+// HAR returns the recorded HAR.
+//
+// The entries must not be modified while recording.
+func (r *HARRecorder) HAR() *HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.har()
+}
+
+func (r *HARRecorder) har() *HAR {
+	entries := make([]*HAREntry, len(r.entries))
+	copy(entries, r.entries)
+	return &HAR{
+		Log: &HARLog{
+			Version: "1.2",
+			Creator: &HARCreator{Name: modulePath, Version: moduleVersion()},
+			Entries: entries,
+		},
+	}
+}
+
+// Save is an action that saves the recorded HAR as json file.
+//
+// The file is written atomically with mode 0600, because it contains cookies and possibly bodies.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func (r *HARRecorder) Save(filename interface{}) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		r.mu.Lock()
+		b, err := json.MarshalIndent(r.har(), "", "  ")
+		r.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(toString(filename), 0600, func(w io.Writer) error {
+			_, err := w.Write(b)
+			return err
+		})
+	})
+}
+
+// RecordHAR is an action that records HAR while running acts and saves it as json file.
+//
+// The HAR is saved even if acts failed.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func RecordHAR(filename interface{}, bodies bool, acts ...chromedp.Action) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		r := &HARRecorder{Bodies: bodies}
+		if err := r.Start().Do(ctx); err != nil {
+			return err
+		}
+		var actErr error
+		for _, a := range acts {
+			if actErr = a.Do(ctx); actErr != nil {
+				break
+			}
+		}
+		if err := r.Stop().Do(ctx); err != nil && actErr == nil {
+			actErr = err
+		}
+		if err := r.Save(filename).Do(ctx); err != nil && actErr == nil {
+			actErr = err
+		}
+		return actErr
+	})
+}
+
+func (r *HARRecorder) handle(ctx context.Context, session int, ev interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped || session != r.session {
+		return
+	}
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if p, ok := r.pending[e.RequestID]; ok && e.RedirectResponse != nil {
+			p.setResponse(e.RedirectResponse)
+			p.entry.Response.RedirectURL = e.Request.URL
+			p.finish(timeOf(e.Timestamp))
+			delete(r.pending, e.RequestID)
+		}
+		p := &harPending{
+			entry: newHAREntry(e),
+			start: timeOf(e.Timestamp),
+		}
+		r.pending[e.RequestID] = p
+		r.entries = append(r.entries, p.entry)
+
+	case *network.EventResponseReceived:
+		if p, ok := r.pending[e.RequestID]; ok {
+			p.setResponse(e.Response)
+		}
+
+	case *network.EventLoadingFinished:
+		p, ok := r.pending[e.RequestID]
+		if !ok {
+			return
+		}
+		delete(r.pending, e.RequestID)
+		p.entry.Response.BodySize = int64(e.EncodedDataLength)
+		p.finish(timeOf(e.Timestamp))
+		if r.Bodies {
+			r.bodies.Add(1)
+			// fetch in another goroutine because actions cannot be run in the listener
+			go func() {
+				defer r.bodies.Done()
+				body, err := network.GetResponseBody(e.RequestID).Do(ctx)
+				if err != nil {
+					log.Printf("HARRecorder: error=%v url=%s\n", err, p.entry.Request.URL)
+					return
+				}
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				p.entry.Response.Content.setBody(body)
+			}()
+		}
+
+	case *network.EventLoadingFailed:
+		p, ok := r.pending[e.RequestID]
+		if !ok {
+			return
+		}
+		delete(r.pending, e.RequestID)
+		p.entry.Comment = e.ErrorText
+		p.finish(timeOf(e.Timestamp))
+	}
+}
+
+func newHAREntry(e *network.EventRequestWillBeSent) *HAREntry {
+	req := e.Request
+	header := toHTTPHeader(req.Headers)
+	entry := &HAREntry{
+		Request: &HARRequest{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: "",
+			Cookies:     requestCookies(header),
+			Headers:     toHARNameValues(header),
+			QueryString: make([]*HARNameValue, 0),
+			HeadersSize: -1,
+			BodySize:    int64(len(req.PostData)),
+		},
+		Response: &HARResponse{
+			Cookies: make([]*HARCookie, 0),
+			Headers: make([]*HARNameValue, 0),
+			Content: &HARContent{},
+		},
+		Timings: &HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+	}
+	if e.WallTime != nil {
+		entry.StartedDateTime = e.WallTime.Time()
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		entry.Request.QueryString = toHARNameValues(u.Query())
+	}
+	if req.PostData != "" {
+		entry.Request.PostData = &HARPostData{
+			MimeType: header.Get("Content-Type"),
+			Text:     req.PostData,
+		}
+	}
+	return entry
+}
+
+func (p *harPending) setResponse(res *network.Response) {
+	header := toHTTPHeader(res.Headers)
+	p.entry.Request.HTTPVersion = res.Protocol
+	p.entry.ServerIPAddress = res.RemoteIPAddress
+	p.entry.Response = &HARResponse{
+		Status:      res.Status,
+		StatusText:  res.StatusText,
+		HTTPVersion: res.Protocol,
+		Cookies:     responseCookies(header),
+		Headers:     toHARNameValues(header),
+		Content: &HARContent{
+			MimeType: res.MimeType,
+		},
+		RedirectURL: header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	p.timing = res.Timing
+}
+
+func (p *harPending) finish(end time.Time) {
+	total := float64(end.Sub(p.start)) / float64(time.Millisecond)
+	if total < 0 {
+		total = 0
+	}
+	p.entry.Time = total
+	p.entry.Response.Content.Size = p.entry.Response.BodySize
+	t := p.entry.Timings
+	timing := p.timing
+	if timing == nil {
+		t.Wait = total
+		return
+	}
+	for _, start := range []float64{timing.DNSStart, timing.ConnectStart, timing.SendStart} {
+		if start >= 0 {
+			t.Blocked = start
+			break
+		}
+	}
+	t.DNS = duration(timing.DNSStart, timing.DNSEnd)
+	t.Connect = duration(timing.ConnectStart, timing.ConnectEnd)
+	t.SSL = duration(timing.SslStart, timing.SslEnd)
+	t.Send = timing.SendEnd - timing.SendStart
+	t.Wait = timing.ReceiveHeadersEnd - timing.SendEnd
+	t.Receive = total - timing.ReceiveHeadersEnd
+	if t.Receive < 0 {
+		t.Receive = 0
+	}
+}
+
+func (c *HARContent) setBody(body []byte) {
+	c.Size = int64(len(body))
+	if utf8.Valid(body) {
+		c.Text = string(body)
+		c.Encoding = ""
+		return
+	}
+	c.Text = base64.StdEncoding.EncodeToString(body)
+	c.Encoding = "base64"
+}
+
+func duration(start, end float64) float64 {
+	if start < 0 {
+		return -1
+	}
+	return end - start
+}
+
+func timeOf(t *cdp.MonotonicTime) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time()
+}
+
+func toHARNameValues(values map[string][]string) []*HARNameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	nvs := make([]*HARNameValue, 0, len(values))
+	for _, name := range names {
+		for _, v := range values[name] {
+			nvs = append(nvs, &HARNameValue{Name: name, Value: v})
+		}
+	}
+	return nvs
+}
+
+func requestCookies(header http.Header) []*HARCookie {
+	cookies := (&http.Request{Header: header}).Cookies()
+	res := make([]*HARCookie, 0, len(cookies))
+	for _, c := range cookies {
+		res = append(res, &HARCookie{Name: c.Name, Value: c.Value})
+	}
+	return res
+}
+
+func responseCookies(header http.Header) []*HARCookie {
+	cookies := (&http.Response{Header: header}).Cookies()
+	res := make([]*HARCookie, 0, len(cookies))
+	for _, c := range cookies {
+		hc := &HARCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			expires := c.Expires
+			hc.Expires = &expires
+		}
+		res = append(res, hc)
+	}
+	return res
+}
+
+func moduleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath {
+			return info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
@@ -0,0 +1,240 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func TestHARRecorderHandle(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	mono := func(ms int) *cdp.MonotonicTime {
+		t := cdp.MonotonicTime(base.Add(time.Duration(ms) * time.Millisecond))
+		return &t
+	}
+	wall := cdp.TimeSinceEpoch(base)
+
+	r := &HARRecorder{pending: make(map[network.RequestID]*harPending)}
+	ctx := context.Background()
+	events := []interface{}{
+		&network.EventRequestWillBeSent{
+			RequestID: "1",
+			Request: &network.Request{
+				URL:     "https://example.com/old?q=1",
+				Method:  "GET",
+				Headers: network.Headers{"Cookie": "a=b"},
+			},
+			Timestamp: mono(0),
+			WallTime:  &wall,
+		},
+		&network.EventRequestWillBeSent{
+			RequestID: "1",
+			Request: &network.Request{
+				URL:    "https://example.com/new",
+				Method: "GET",
+			},
+			RedirectResponse: &network.Response{
+				Status:     302,
+				StatusText: "Found",
+				Headers:    network.Headers{"Location": "/new"},
+			},
+			Timestamp: mono(10),
+			WallTime:  &wall,
+		},
+		&network.EventResponseReceived{
+			RequestID: "1",
+			Response: &network.Response{
+				Status:     200,
+				StatusText: "OK",
+				Headers:    network.Headers{"Content-Type": "text/html", "Set-Cookie": "c=d; Path=/; HttpOnly"},
+				MimeType:   "text/html",
+				Protocol:   "http/1.1",
+				Timing: &network.ResourceTiming{
+					DNSStart:          -1,
+					DNSEnd:            -1,
+					ConnectStart:      -1,
+					ConnectEnd:        -1,
+					SslStart:          -1,
+					SslEnd:            -1,
+					SendStart:         1,
+					SendEnd:           2,
+					ReceiveHeadersEnd: 20,
+				},
+			},
+		},
+		&network.EventLoadingFinished{
+			RequestID:         "1",
+			Timestamp:         mono(40),
+			EncodedDataLength: 100,
+		},
+		&network.EventRequestWillBeSent{
+			RequestID: "2",
+			Request: &network.Request{
+				URL:    "https://example.com/image.png",
+				Method: "GET",
+			},
+			Timestamp: mono(50),
+			WallTime:  &wall,
+		},
+		&network.EventLoadingFailed{
+			RequestID: "2",
+			Timestamp: mono(55),
+			ErrorText: "net::ERR_FAILED",
+		},
+	}
+	for _, ev := range events {
+		r.handle(ctx, 0, ev)
+	}
+
+	entries := r.HAR().Log.Entries
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	redirect := entries[0]
+	if redirect.Response.Status != 302 || redirect.Response.RedirectURL != "https://example.com/new" {
+		t.Fatalf("unexpected redirect response: %+v", redirect.Response)
+	}
+	if len(redirect.Request.QueryString) != 1 || redirect.Request.QueryString[0].Name != "q" {
+		t.Fatalf("unexpected query string: %+v", redirect.Request.QueryString)
+	}
+	if len(redirect.Request.Cookies) != 1 || redirect.Request.Cookies[0].Name != "a" {
+		t.Fatalf("unexpected request cookies: %+v", redirect.Request.Cookies)
+	}
+	if redirect.Time != 10 {
+		t.Fatalf("expected redirect time to be 10, got %v", redirect.Time)
+	}
+
+	final := entries[1]
+	if final.Response.Status != 200 || final.Response.BodySize != 100 {
+		t.Fatalf("unexpected final response: %+v", final.Response)
+	}
+	if len(final.Response.Cookies) != 1 || !final.Response.Cookies[0].HTTPOnly {
+		t.Fatalf("unexpected response cookies: %+v", final.Response.Cookies)
+	}
+	wantTimings := HARTimings{Blocked: 1, DNS: -1, Connect: -1, SSL: -1, Send: 1, Wait: 18, Receive: 10}
+	if *final.Timings != wantTimings {
+		t.Fatalf("\nwant: %+v\n got: %+v", wantTimings, *final.Timings)
+	}
+
+	failed := entries[2]
+	if failed.Comment != "net::ERR_FAILED" || failed.Response.Status != 0 {
+		t.Fatalf("unexpected failed entry: %+v", failed)
+	}
+
+	// events delivered after Stop are ignored
+	if err := r.Stop().Do(ctx); err != nil {
+		t.Fatal(err)
+	}
+	r.handle(ctx, 0, events[0])
+	if got := len(r.HAR().Log.Entries); got != 3 {
+		t.Fatalf("%#v != %#v", got, 3)
+	}
+}
+
+func TestHARContent(t *testing.T) {
+	t.Parallel()
+	for _, body := range [][]byte{[]byte("text"), {0xff, 0xfe, 0x00}} {
+		c := &HARContent{}
+		c.setBody(body)
+		got, err := c.Body()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(body) {
+			t.Fatalf("%#v != %#v", got, body)
+		}
+	}
+}
+
+func TestHARRecorderSave(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	harpath := filepath.Join(dir, "test.har")
+
+	r := &HARRecorder{}
+	if err := r.Save(harpath).Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(harpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0600 {
+		t.Fatalf("expected mode to be 0600, got %o", perm)
+	}
+	b, err := ioutil.ReadFile(harpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got HAR
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Log.Version != "1.2" || got.Log.Creator.Name != modulePath {
+		t.Fatalf("unexpected log: %+v", got.Log)
+	}
+	if got.Log.Entries == nil {
+		t.Fatal("expected entries to be an empty array")
+	}
+}
+
+func TestRecordHAR(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	harpath := filepath.Join(dir, "test.har")
+
+	tasks := chromedp.Tasks{
+		network.Enable(),
+		EnableLifeCycleEvents(),
+		RecordHAR(harpath, true,
+			Navigate(endpoint+"/navigate.html", 5*time.Second),
+		),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(harpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got HAR
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range got.Log.Entries {
+		if e.Request.URL == endpoint+"/navigate.html" {
+			if e.Response.Status != 200 {
+				t.Fatalf("expected status to be 200, got %d", e.Response.Status)
+			}
+			if !strings.Contains(e.Response.Content.Text, `id="text"`) {
+				t.Fatalf("unexpected content: %s", e.Response.Content.Text)
+			}
+			return
+		}
+	}
+	t.Fatalf("entry of navigate.html is not recorded: %+v", got.Log.Entries)
+}
@@ -1,17 +1,15 @@
 package helper
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"log"
 	"math"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/chromedp/cdproto/cdp"
@@ -21,11 +19,6 @@ import (
 	"github.com/chromedp/chromedp"
 )
 
-var (
-	// ErrCanceledByUser is an error because of canceled by user.
-	ErrCanceledByUser = errors.New("canceled by user")
-)
-
 // Screenshot is an action that takes a screenshot of the entire browser viewport and save as image file.
 //
 // Note: this will override the viewport emulation settings.
@@ -34,7 +27,11 @@ var (
 //
 // filename can be specified by string, string pointer or fmt.Stringer.
 func Screenshot(filename interface{}) chromedp.Action {
-	return chromedp.ActionFunc(func(ctx context.Context) error {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		name := toString(filename)
+		ctx, span := startSpan(ctx, "Screenshot", AttrFilename.String(name))
+		defer func() { endSpan(span, err) }()
+
 		// get layout metrics
 		_, _, contentSize, err := page.GetLayoutMetrics().Do(ctx)
 		if err != nil {
@@ -69,17 +66,20 @@ func Screenshot(filename interface{}) chromedp.Action {
 		}
 
 		// save screenshot
-		f, err := os.Create(toString(filename))
+		f, err := os.Create(name)
 		if err != nil {
 			return err
 		}
 		defer f.Close()
-		if _, err := f.Write(res); err != nil {
+		n, err := f.Write(res)
+		span.SetAttributes(AttrBytes.Int(n))
+		if err != nil {
 			return err
 		}
 		if err := f.Sync(); err != nil {
 			return err
 		}
+		metrics(ctx).Screenshot(n)
 
 		return nil
 	})
@@ -87,18 +87,26 @@ func Screenshot(filename interface{}) chromedp.Action {
 
 // Navigate is an action that navigates the current frame.
 //
+// It returns the errors of WaitResponse.
+//
 // urlstr can be specified by string, string pointer or fmt.Stringer.
 func Navigate(urlstr interface{}, timeout time.Duration) chromedp.NavigateAction {
-	return WaitResponse(urlstr, timeout,
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			u := toString(urlstr)
-			_, _, _, err := page.Navigate(u).Do(ctx)
-			return err
-		}),
-	)
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		u := toString(urlstr)
+		ctx, span := startSpan(ctx, "Navigate", AttrURL.String(u))
+		defer func() { endSpan(span, err) }()
+		return WaitResponse(u, timeout,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				_, _, _, err := page.Navigate(u).Do(ctx)
+				return err
+			}),
+		).Do(ctx)
+	})
 }
 
 // IgnoreCacheReload is an action that reloads the current page without cache.
+//
+// It returns the errors of WaitResponse.
 func IgnoreCacheReload(timeout time.Duration) chromedp.NavigateAction {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
 		_, entries, err := page.GetNavigationHistory().Do(ctx)
@@ -135,11 +143,28 @@ func EnableLifeCycleEvents() chromedp.Action {
 
 // WaitResponse is an action that waits until response received or timeout exceeded.
 //
+// It returns *StatusError if the response status is Bad Request or Gone,
+// *NetworkError if the document is blocked and
+// *TimeoutError wrapping the last failure if timeout exceeded and WithTimeoutErrors is set to ctx.
+// It returns nil if timeout exceeded by default.
+// The blocked requests other than documents are ignored.
+//
 // urlstr can be specified by string, string pointer or fmt.Stringer.
 func WaitResponse(urlstr interface{}, timeout time.Duration, acts ...chromedp.Action) chromedp.Action {
-	return chromedp.ActionFunc(func(ctx context.Context) error {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
 		u := toString(urlstr)
+		ctx, span := startSpan(ctx, "WaitResponse", AttrURL.String(u))
+		reloads := 0
+		defer func() {
+			span.SetAttributes(AttrReloads.Int(reloads))
+			endSpan(span, err)
+		}()
+		requests := newRequestSpans(ctx, span)
+		defer requests.endAll()
+
 		log.Printf("WaitResponse: wait for url=%s\n", u)
+		c := clock(ctx)
+		start := c.Now()
 		ch := make(chan error, 1)
 		reloadCh := make(chan struct{}, 1)
 		lctx, cancel := context.WithCancel(ctx)
@@ -148,20 +173,46 @@ func WaitResponse(urlstr interface{}, timeout time.Duration, acts ...chromedp.Ac
 		var requestID network.RequestID
 		var loaderID cdp.LoaderID
 		var frameID cdp.FrameID
+		var mu sync.Mutex
+		var lastErr error
+		setLastErr := func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			lastErr = err
+		}
 		chromedp.ListenTarget(lctx, func(ev interface{}) {
 			switch e := ev.(type) {
 			// Wait request event
 			case *network.EventRequestWillBeSent:
 				req := e.Request
+				requests.start(e.RequestID, req.Method, req.URL)
 				if strings.HasPrefix(req.URL, u) {
 					requestID = e.RequestID
 					log.Printf("WaitResponse: request id=%s method=%s url=%s", e.RequestID, req.Method, req.URL)
 				}
 
+			case *network.EventLoadingFinished:
+				requests.end(e.RequestID, nil)
+
 			// Handle network error
 			case *network.EventLoadingFailed:
+				requests.end(e.RequestID, errors.New(e.ErrorText))
 				if requestID == e.RequestID {
+					if isBlocked(e) && e.Type != network.ResourceTypeDocument {
+						return
+					}
 					log.Printf("WaitResponse: error=%s url=%s\n", e.ErrorText, u)
+					err := &NetworkError{
+						URL:           u,
+						ErrorText:     e.ErrorText,
+						Canceled:      e.Canceled,
+						BlockedReason: e.BlockedReason,
+					}
+					if isBlocked(e) {
+						ch <- err
+						return
+					}
+					setLastErr(err)
 					select {
 					case reloadCh <- struct{}{}:
 					default:
@@ -171,20 +222,22 @@ func WaitResponse(urlstr interface{}, timeout time.Duration, acts ...chromedp.Ac
 			// Wait response
 			case *network.EventResponseReceived:
 				res := e.Response
+				requests.status(e.RequestID, res.Status)
 				if strings.HasPrefix(res.URL, u) {
 					log.Printf("WaitResponse: response status=%d url=%s\n", res.Status, res.URL)
+					span.SetAttributes(AttrStatus.Int64(res.Status))
+					metrics(ctx).Navigation(res.Status)
 					if res.Status >= 200 && res.Status < 400 {
 						loaderID, frameID = e.LoaderID, e.FrameID
 						return
 					}
+					err := &StatusError{URL: u, Status: res.Status, Headers: res.Headers}
 					switch res.Status {
-					case http.StatusBadRequest:
-						ch <- fmt.Errorf("status=BadRequest url=%s", u)
-						return
-					case http.StatusGone:
-						ch <- fmt.Errorf("status=Gone url=%s", u)
+					case http.StatusBadRequest, http.StatusGone:
+						ch <- err
 						return
 					}
+					setLastErr(err)
 					reloadCh <- struct{}{}
 				}
 
@@ -224,9 +277,9 @@ func WaitResponse(urlstr interface{}, timeout time.Duration, acts ...chromedp.Ac
 			}
 		}
 		log.Printf("WaitResponse: timeout=%s\n", timeout)
-		timer := time.NewTimer(timeout)
+		timer := c.NewTimer(timeout)
 		defer timer.Stop()
-		ticker := time.NewTicker(time.Second)
+		ticker := c.NewTicker(time.Second)
 		defer ticker.Stop()
 		for {
 			select {
@@ -236,17 +289,23 @@ func WaitResponse(urlstr interface{}, timeout time.Duration, acts ...chromedp.Ac
 				}
 				if open {
 					log.Println("WaitResponse: reload")
-					<-ticker.C
+					reloads++
+					metrics(ctx).Reload()
+					<-ticker.C()
 					if err := page.Reload().Do(ctx); err != nil {
 						return err
 					}
 					continue
 				}
 				log.Println("WaitResponse: loaded")
+				metrics(ctx).PageLoad(c.Now().Sub(start))
 				return nil
-			case <-timer.C:
+			case <-timer.C():
 				log.Printf("WaitResponse: timeout exceeded url=%s\n", u)
-				return nil
+				metrics(ctx).Timeout("WaitResponse")
+				mu.Lock()
+				defer mu.Unlock()
+				return timeoutError(ctx, &TimeoutError{Action: "WaitResponse", URL: u, Timeout: timeout, Err: lastErr})
 			case <-ctx.Done():
 				return ctx.Err()
 			}
@@ -255,6 +314,8 @@ func WaitResponse(urlstr interface{}, timeout time.Duration, acts ...chromedp.Ac
 }
 
 // WaitLoaded is an action that waits until load event fired or timeout exceeded.
+//
+// It returns *TimeoutError if timeout exceeded and WithTimeoutErrors is set to ctx, and nil by default.
 func WaitLoaded(timeout time.Duration) chromedp.Action {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
 		ch := make(chan struct{})
@@ -266,14 +327,18 @@ func WaitLoaded(timeout time.Duration) chromedp.Action {
 			}
 		})
 		log.Printf("WaitLoaded: timeout=%s\n", timeout)
-		timer := time.NewTimer(timeout)
+		c := clock(ctx)
+		start := c.Now()
+		timer := c.NewTimer(timeout)
 		defer timer.Stop()
 		select {
 		case <-ch:
+			metrics(ctx).PageLoad(c.Now().Sub(start))
 			return nil
-		case <-timer.C:
+		case <-timer.C():
 			log.Println("WaitLoaded: timeout exceeded")
-			return nil
+			metrics(ctx).Timeout("WaitLoaded")
+			return timeoutError(ctx, &TimeoutError{Action: "WaitLoaded", Timeout: timeout})
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -281,43 +346,23 @@ func WaitLoaded(timeout time.Duration) chromedp.Action {
 }
 
 // WaitInput is an action that waits until input.
+//
+// It returns ErrCanceledByUser if expected is not empty and the input is none of expected.
+// message is written to the writer set by WithPromptWriter, and os.Stdin is read by the shared LineReader.
+// Use Ask for timeouts, default answers and case-insensitive choices.
 func WaitInput(r io.Reader, message string, expected ...string) chromedp.Action {
-	return chromedp.ActionFunc(func(ctx context.Context) error {
-		fmt.Print(message)
-		ch := make(chan string)
-		go func() {
-			s := bufio.NewScanner(r)
-			s.Scan()
-			ch <- strings.TrimSpace(s.Text())
-		}()
-		select {
-		case input := <-ch:
-			if len(expected) == 0 {
-				log.Println("WaitTerminalInput: Confirmed")
-				return nil
-			}
-			for _, exp := range expected {
-				if input == exp {
-					log.Println("WaitTerminalInput: Confirmed")
-					return nil
-				}
-			}
-			log.Println("WaitTerminalInput: Canceled")
-			return ErrCanceledByUser
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	})
+	return Ask(r, &Prompt{Message: message, Choices: expected}, nil)
 }
 
 // WaitForTime is an action that waits until for time.
 func WaitForTime(t time.Time) chromedp.Action {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
 		log.Printf("WaitForTime: %s\n", t)
-		timer := time.NewTimer(time.Until(t))
+		c := clock(ctx)
+		timer := c.NewTimer(t.Sub(c.Now()))
 		defer timer.Stop()
 		select {
-		case <-timer.C:
+		case <-timer.C():
 			return nil
 		case <-ctx.Done():
 			return ctx.Err()
@@ -329,92 +374,15 @@ func WaitForTime(t time.Time) chromedp.Action {
 //
 // filename can be specified by string, string pointer or fmt.Stringer.
 func SaveCookies(filename interface{}, maps ...func(*network.Cookie)) chromedp.Action {
-	mapFunc := func(c *network.Cookie) {
-		for _, m := range maps {
-			m(c)
-		}
-	}
-	return chromedp.ActionFunc(func(ctx context.Context) error {
-		cookies, err := network.GetAllCookies().Do(ctx)
-		if err != nil {
-			return err
-		}
-
-		log.Printf("SaveCookies: cookie(s)=%d\n", len(cookies))
-		f, err := os.OpenFile(toString(filename), os.O_WRONLY|os.O_CREATE, 0644)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		if err := f.Truncate(0); err != nil {
-			return err
-		}
-		e := json.NewEncoder(f)
-		for _, c := range cookies {
-			mapFunc(c)
-			e.Encode(c)
-		}
-
-		return f.Sync()
-	})
+	return SaveCookiesTo(NewFileCookieStore(filename), maps...)
 }
 
 // RestoreCookies is an action that restores cookies from json lines file.
 //
+// filters are applied in order and can modify the cookie to restore.
+// Session cookies are restored as session cookies.
+//
 // filename can be specified by string, string pointer or fmt.Stringer.
 func RestoreCookies(filename interface{}, filters ...func(*network.Cookie) bool) chromedp.Action {
-	filter := func(c network.Cookie) bool {
-		for _, f := range filters {
-			if !f(&c) {
-				return false
-			}
-		}
-		return true
-	}
-	return chromedp.ActionFunc(func(ctx context.Context) error {
-		f, err := os.Open(toString(filename))
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return nil
-			}
-			return err
-		}
-		defer f.Close()
-		d := json.NewDecoder(f)
-		cookies := make([]*network.Cookie, 0)
-		for {
-			var c network.Cookie
-			if err := d.Decode(&c); err != nil {
-				if errors.Is(err, io.EOF) {
-					break
-				}
-				return err
-			}
-			if filter(c) {
-				cookies = append(cookies, &c)
-			}
-		}
-		log.Printf("RestoreCookies: cookie(s)=%d\n", len(cookies))
-
-		// add cookies to browser
-		for _, c := range cookies {
-			expires := cdp.TimeSinceEpoch(time.Time{}.Add(time.Duration(c.Expires) * time.Second))
-			success, err := network.SetCookie(c.Name, c.Value).
-				WithDomain(c.Domain).
-				WithPath(c.Path).
-				WithSecure(c.Secure).
-				WithHTTPOnly(c.HTTPOnly).
-				WithSameSite(c.SameSite).
-				WithExpires(&expires).
-				WithPriority(c.Priority).
-				Do(ctx)
-			if err != nil {
-				return err
-			}
-			if !success {
-				return fmt.Errorf("could not set cookie %s to %s", c.Name, c.Value)
-			}
-		}
-		return nil
-	})
+	return RestoreCookiesFrom(NewFileCookieStore(filename), filters...)
 }
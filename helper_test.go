@@ -37,6 +37,8 @@ var (
 	testServer      *httptest.Server
 	testdataDir     string
 	testdataURL     string
+
+	testCookieExpires = time.Now().Add(24 * time.Hour).Truncate(time.Second)
 )
 
 func init() {
@@ -144,6 +146,37 @@ func testStartServer(tb testing.TB) string {
 			w.WriteHeader(http.StatusOK)
 			io.WriteString(w, "ok")
 		})
+		mux.HandleFunc("/persistent-cookies", func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{
+				Name:    "test-persistent-cookie",
+				Value:   "testval",
+				Path:    "/",
+				Expires: testCookieExpires,
+			})
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, "ok")
+		})
+		mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "test-login", Value: "ok", Path: "/"})
+			io.WriteString(w, "ok")
+		})
+		mux.HandleFunc("/mypage", func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Cookie("test-login"); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `<div id="account">ok</div>`)
+		})
+		mux.HandleFunc("/challenge", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `<div id="captcha">captcha</div>`+
+				`<script>setTimeout(function() { document.getElementById("captcha").remove(); }, 500);</script>`)
+		})
+		mux.HandleFunc("/release", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `<button id="buy" onclick="window.clickedAt = Date.now()">buy</button>`)
+		})
+		mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		})
 		mux.Handle("/", http.FileServer(http.Dir(testdataDir)))
 		testServer = httptest.NewServer(mux)
 	})
@@ -373,27 +406,30 @@ func TestWaitInput(t *testing.T) {
 
 func TestWaitForTime(t *testing.T) {
 	t.Parallel()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 	tests := []struct {
-		name string
-		t    time.Time
-		ctx  context.Context
-		want error
+		name    string
+		t       time.Time
+		advance time.Duration
+		ctx     context.Context
+		want    error
 	}{
 		{
 			name: "past time",
-			t:    time.Now().Add(-time.Hour),
+			t:    now.Add(-time.Hour),
 			ctx:  context.Background(),
 			want: nil,
 		},
 		{
-			name: "future time",
-			t:    time.Now().Add(300 * time.Millisecond),
-			ctx:  context.Background(),
-			want: nil,
+			name:    "future time",
+			t:       now.Add(time.Hour),
+			advance: time.Hour,
+			ctx:     context.Background(),
+			want:    nil,
 		},
 		{
 			name: "context canceled",
-			t:    time.Now().Add(time.Hour),
+			t:    now.Add(time.Hour),
 			ctx: func() context.Context {
 				ctx, cancel := context.WithCancel(context.Background())
 				defer cancel()
@@ -403,11 +439,17 @@ func TestWaitForTime(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			ctx, cancel := context.WithTimeout(tt.ctx, time.Second)
-			defer cancel()
-			got := WaitForTime(tt.t).Do(ctx)
-			if got != tt.want {
+			t.Parallel()
+			c := NewFakeClock(now)
+			done := make(chan error, 1)
+			go func() { done <- WaitForTime(tt.t).Do(WithClock(tt.ctx, c)) }()
+			if tt.advance > 0 {
+				c.BlockUntil(1)
+				c.Advance(tt.advance)
+			}
+			if got := <-done; got != tt.want {
 				t.Fatalf("%#v != %#v", got, tt.want)
 			}
 		})
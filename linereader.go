@@ -0,0 +1,115 @@
+package helper
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LineReader reads lines from a reader shared by sequential prompts.
+//
+// It reads the underlying reader with a single goroutine, so no buffered input is lost between prompts
+// and canceled prompts leave no goroutine blocked on the reader.
+// It implements io.Reader, so it can be passed to WaitInput, Ask and Choose.
+type LineReader struct {
+	r     io.Reader
+	once  sync.Once
+	lines chan string
+	err   error
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+var _ io.Reader = (*LineReader)(nil)
+
+var stdinLineReader = NewLineReader(os.Stdin)
+
+// NewLineReader returns a new LineReader which reads r.
+func NewLineReader(r io.Reader) *LineReader {
+	return &LineReader{r: r, lines: make(chan string)}
+}
+
+// Stdin returns the LineReader of os.Stdin shared by all prompts.
+//
+// Prompts reading os.Stdin use it implicitly.
+func Stdin() *LineReader {
+	return stdinLineReader
+}
+
+func (l *LineReader) start() {
+	go func() {
+		s := bufio.NewScanner(l.r)
+		for s.Scan() {
+			l.lines <- s.Text()
+		}
+		l.err = s.Err()
+		if l.err == nil {
+			l.err = io.EOF
+		}
+		close(l.lines)
+	}()
+}
+
+// ReadLine returns the next line without the line terminator.
+//
+// It returns io.EOF at the end of the input.
+func (l *LineReader) ReadLine(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	if len(l.buf) > 0 {
+		s := strings.TrimSuffix(string(l.buf), "\n")
+		l.buf = nil
+		l.mu.Unlock()
+		return s, nil
+	}
+	l.mu.Unlock()
+	return l.readLine(ctx)
+}
+
+func (l *LineReader) readLine(ctx context.Context) (string, error) {
+	l.once.Do(l.start)
+	select {
+	case s, ok := <-l.lines:
+		if !ok {
+			return "", l.err
+		}
+		return s, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Read implements io.Reader.
+func (l *LineReader) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buf) == 0 {
+		s, err := l.readLine(context.Background())
+		if err != nil {
+			return 0, err
+		}
+		l.buf = append([]byte(s), '\n')
+	}
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
+
+type promptWriterKey struct{}
+
+// WithPromptWriter returns a copy of ctx in which the prompts of WaitInput, Ask and Choose are written to w.
+//
+// The prompts are written to os.Stdout by default.
+func WithPromptWriter(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, promptWriterKey{}, w)
+}
+
+func promptWriter(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(promptWriterKey{}).(io.Writer); ok && w != nil {
+		return w
+	}
+	return os.Stdout
+}
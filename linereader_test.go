@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestLineReaderPrompts(t *testing.T) {
+	t.Parallel()
+	r := NewLineReader(bytes.NewBufferString("yes\n2\nrest\n"))
+	var out bytes.Buffer
+	ctx := WithPromptWriter(context.Background(), &out)
+
+	var answer string
+	var selected int
+	if err := Ask(r, &Prompt{Message: "continue? ", Choices: []string{"yes", "no"}}, &answer).Do(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := Choose(r, &Prompt{Message: "account? ", Choices: []string{"a", "b"}}, &selected).Do(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if answer != "yes" || selected != 1 {
+		t.Fatalf("invalid answers: answer=%#v selected=%#v", answer, selected)
+	}
+	if want := "continue? 1) a\n2) b\naccount? "; out.String() != want {
+		t.Fatalf("%#v != %#v", out.String(), want)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "rest\n" {
+		t.Fatalf("%#v != %#v", string(b), "rest\n")
+	}
+	if _, err := r.ReadLine(context.Background()); err != io.EOF {
+		t.Fatalf("%#v != %#v", err, io.EOF)
+	}
+}
+
+func TestLineReaderCanceled(t *testing.T) {
+	t.Parallel()
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	r := NewLineReader(pr)
+	ctx := WithPromptWriter(context.Background(), ioutil.Discard)
+
+	err := Ask(r, &Prompt{Timeout: 10 * time.Millisecond}, nil).Do(ctx)
+	var terr *TimeoutError
+	if !errors.As(err, &terr) {
+		t.Fatalf("expected TimeoutError, got %#v", err)
+	}
+
+	go io.WriteString(pw, "next\n")
+	var got string
+	if err := Ask(r, &Prompt{Timeout: time.Second}, &got).Do(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got != "next" {
+		t.Fatalf("%#v != %#v", got, "next")
+	}
+}
+
+func TestLineReaderPartialRead(t *testing.T) {
+	t.Parallel()
+	r := NewLineReader(bytes.NewBufferString("abcdef\nnext\n"))
+	p := make([]byte, 3)
+	if n, err := r.Read(p); err != nil || string(p[:n]) != "abc" {
+		t.Fatalf("unexpected read: %q %v", p[:n], err)
+	}
+	got, err := r.ReadLine(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "def" {
+		t.Fatalf("%#v != %#v", got, "def")
+	}
+	got, err = r.ReadLine(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "next" {
+		t.Fatalf("%#v != %#v", got, "next")
+	}
+}
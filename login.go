@@ -0,0 +1,143 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// LoginProbe reports whether the browser is logged in.
+type LoginProbe func(ctx context.Context) (bool, error)
+
+// ProbeStatus returns a LoginProbe which navigates to urlstr and reports whether the status of the document is any of statuses.
+//
+// Any 2xx status means logged in if statuses is empty.
+// Redirects are followed, so the status is of the final document.
+// It enables the Network domain to receive the response, and returns *TimeoutError if no document response is received.
+//
+// urlstr can be specified by string, string pointer or fmt.Stringer.
+func ProbeStatus(urlstr interface{}, statuses ...int64) LoginProbe {
+	return func(ctx context.Context) (bool, error) {
+		status, err := probeNavigate(ctx, toString(urlstr))
+		if err != nil {
+			return false, err
+		}
+		if len(statuses) == 0 {
+			return status >= 200 && status < 300, nil
+		}
+		for _, s := range statuses {
+			if status == s {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// ProbeSelector returns a LoginProbe which navigates to urlstr and reports whether the document has an element matching sel.
+//
+// urlstr can be specified by string, string pointer or fmt.Stringer.
+func ProbeSelector(urlstr interface{}, sel string) LoginProbe {
+	return func(ctx context.Context) (bool, error) {
+		if err := chromedp.Navigate(toString(urlstr)).Do(ctx); err != nil {
+			return false, err
+		}
+		return elementExists(ctx, sel)
+	}
+}
+
+// probeNavigate navigates to u and returns the status of the first document response.
+//
+// It enables the Network domain to receive the response.
+func probeNavigate(ctx context.Context, u string) (int64, error) {
+	if err := network.Enable().Do(ctx); err != nil {
+		return 0, err
+	}
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := make(chan int64, 1)
+	chromedp.ListenTarget(lctx, func(ev interface{}) {
+		if e, ok := ev.(*network.EventResponseReceived); ok && e.Type == network.ResourceTypeDocument {
+			select {
+			case ch <- e.Response.Status:
+			default:
+			}
+		}
+	})
+	if err := chromedp.Navigate(u).Do(ctx); err != nil {
+		return 0, err
+	}
+	return waitProbeResponse(ctx, ch, u)
+}
+
+// probeResponseTimeout is the duration to wait for the document response after the navigation completes.
+const probeResponseTimeout = 5 * time.Second
+
+// waitProbeResponse waits for the status of the document response sent to ch.
+//
+// The response may be delivered after the navigation completes, and never for same-document navigations,
+// about: and data: URLs, so it returns *TimeoutError if probeResponseTimeout passes on the clock of ctx.
+func waitProbeResponse(ctx context.Context, ch <-chan int64, u string) (int64, error) {
+	timer := clock(ctx).NewTimer(probeResponseTimeout)
+	defer timer.Stop()
+	select {
+	case status := <-ch:
+		log.Printf("LoginProbe: status=%d url=%s\n", status, u)
+		return status, nil
+	case <-timer.C():
+		return 0, &TimeoutError{Action: "LoginProbe", URL: u, Timeout: probeResponseTimeout}
+	case <-ctx.Done():
+		return 0, fmt.Errorf("no response received url=%s: %w", u, ctx.Err())
+	}
+}
+
+// EnsureLoggedIn is an action that runs login actions until probe reports logged in.
+//
+// If probe reports not logged in, login actions are run and probe is checked again, up to maxAttempts times.
+// The cookies are saved to store after logging in if store is not nil.
+// Errors of probe are returned as they are.
+// It returns *LoginError wrapping the last error of login actions if login cannot be established.
+func EnsureLoggedIn(probe LoginProbe, store CookieStore, maxAttempts int, login ...chromedp.Action) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		ctx, span := startSpan(ctx, "EnsureLoggedIn")
+		defer func() { endSpan(span, err) }()
+
+		ok, err := probe(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			log.Println("EnsureLoggedIn: logged in")
+			return nil
+		}
+		var lastErr error
+		for attempt := 1; attempt <= maxAttempts; attempt++ {
+			log.Printf("EnsureLoggedIn: login attempt=%d\n", attempt)
+			if err := chromedp.Tasks(login).Do(ctx); err != nil {
+				log.Printf("EnsureLoggedIn: error=%v attempt=%d\n", err, attempt)
+				lastErr = err
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				continue
+			}
+			ok, err := probe(ctx)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			log.Printf("EnsureLoggedIn: logged in attempt=%d\n", attempt)
+			if store == nil {
+				return nil
+			}
+			return SaveCookiesTo(store).Do(ctx)
+		}
+		return &LoginError{Attempts: maxAttempts, Err: lastErr}
+	})
+}
@@ -0,0 +1,139 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestEnsureLoggedIn(t *testing.T) {
+	t.Parallel()
+	errLogin := errors.New("login failed")
+	errProbe := errors.New("probe failed")
+	tests := []struct {
+		name        string
+		probes      []bool
+		probeErr    error
+		loginErrs   []error
+		maxAttempts int
+		wantLogins  int
+		wantErr     error
+	}{
+		{name: "logged in", probes: []bool{true}, maxAttempts: 3, wantLogins: 0},
+		{name: "login once", probes: []bool{false, true}, maxAttempts: 3, wantLogins: 1},
+		{name: "login retried", probes: []bool{false, false, true}, maxAttempts: 3, wantLogins: 2},
+		{name: "login error retried", probes: []bool{false, true}, loginErrs: []error{errLogin}, maxAttempts: 3, wantLogins: 2},
+		{name: "probe error", probeErr: errProbe, maxAttempts: 3, wantLogins: 0, wantErr: errProbe},
+		{name: "attempts exceeded", probes: []bool{false, false, false}, loginErrs: []error{nil, errLogin}, maxAttempts: 2, wantLogins: 2, wantErr: errLogin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probes, logins := 0, 0
+			probe := func(ctx context.Context) (bool, error) {
+				if tt.probeErr != nil {
+					return false, tt.probeErr
+				}
+				ok := tt.probes[probes]
+				probes++
+				return ok, nil
+			}
+			login := chromedp.ActionFunc(func(ctx context.Context) error {
+				logins++
+				if logins <= len(tt.loginErrs) {
+					return tt.loginErrs[logins-1]
+				}
+				return nil
+			})
+			err := EnsureLoggedIn(probe, nil, tt.maxAttempts, login).Do(context.Background())
+			if logins != tt.wantLogins {
+				t.Errorf("logins: %#v != %#v", logins, tt.wantLogins)
+			}
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%v is not %v", err, tt.wantErr)
+			}
+			var lerr *LoginError
+			if tt.wantErr == errLogin && (!errors.As(err, &lerr) || lerr.Attempts != tt.maxAttempts) {
+				t.Fatalf("expected LoginError, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestEnsureLoggedInBrowser(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocateSeparate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	store := &MemoryCookieStore{}
+	tasks := chromedp.Tasks{
+		// the probes enable the Network domain by themselves if needed
+		EnsureLoggedIn(ProbeSelector(endpoint+"/mypage", "#account"), store, 2, chromedp.Navigate(endpoint+"/login")),
+		EnsureLoggedIn(ProbeStatus(endpoint+"/mypage"), nil, 1, chromedp.ActionFunc(func(ctx context.Context) error {
+			t.Error("login must not run when logged in")
+			return nil
+		})),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	cookies, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 1 || cookies[0].Name != "test-login" {
+		t.Fatalf("invalid cookies: %+v", cookies)
+	}
+}
+
+func TestWaitProbeResponse(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := WithClock(context.Background(), c)
+
+	ch := make(chan int64, 1)
+	ch <- 200
+	if got, err := waitProbeResponse(ctx, ch, "https://example.com/"); err != nil || got != 200 {
+		t.Fatalf("%#v != %#v: %v", got, 200, err)
+	}
+
+	// a probe URL which produces no document response
+	done := make(chan error, 1)
+	go func() {
+		_, err := waitProbeResponse(ctx, make(chan int64), "about:blank")
+		done <- err
+	}()
+	c.BlockUntil(1)
+	c.Advance(probeResponseTimeout)
+	var terr *TimeoutError
+	if err := <-done; !errors.As(err, &terr) || terr.URL != "about:blank" {
+		t.Fatalf("expected TimeoutError, got %#v", err)
+	}
+}
+
+func TestProbeStatusNoResponse(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+
+	var err error
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, err = ProbeStatus("about:blank")(ctx)
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	var terr *TimeoutError
+	if !errors.As(err, &terr) {
+		t.Fatalf("expected TimeoutError, got %#v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsCollector receives measurements reported by the helper actions.
+//
+// Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	// Navigation is called when a response of the waited url is received.
+	Navigation(status int64)
+	// Reload is called when a page is reloaded to retry.
+	Reload()
+	// Timeout is called when the action exceeded its timeout.
+	Timeout(action string)
+	// PageLoad is called when a page is loaded.
+	PageLoad(d time.Duration)
+	// Screenshot is called when a screenshot is saved.
+	Screenshot(bytes int)
+	// CookiesRestored is called when cookies are restored.
+	CookiesRestored(n int)
+}
+
+type metricsCollectorKey struct{}
+
+// WithMetricsCollector returns a copy of ctx in which the helper actions report into c.
+//
+// To share a collector between all browsers of an allocator,
+// attach it to the context passed to chromedp.NewExecAllocator.
+func WithMetricsCollector(ctx context.Context, c MetricsCollector) context.Context {
+	return context.WithValue(ctx, metricsCollectorKey{}, c)
+}
+
+func metrics(ctx context.Context) MetricsCollector {
+	if c, ok := ctx.Value(metricsCollectorKey{}).(MetricsCollector); ok && c != nil {
+		return c
+	}
+	return nopMetrics{}
+}
+
+// nopMetrics is a MetricsCollector which discards all measurements.
+type nopMetrics struct{}
+
+func (nopMetrics) Navigation(int64)       {}
+func (nopMetrics) Reload()                {}
+func (nopMetrics) Timeout(string)         {}
+func (nopMetrics) PageLoad(time.Duration) {}
+func (nopMetrics) Screenshot(int)         {}
+func (nopMetrics) CookiesRestored(int)    {}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no collector", func(t *testing.T) {
+		if _, ok := metrics(context.Background()).(nopMetrics); !ok {
+			t.Fatal("expected nop collector")
+		}
+	})
+
+	t.Run("with collector", func(t *testing.T) {
+		c := NewPrometheusCollector("test")
+		ctx := WithMetricsCollector(context.Background(), c)
+		if got := metrics(ctx); got != c {
+			t.Fatalf("%#v != %#v", got, c)
+		}
+	})
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	t.Parallel()
+	c := NewPrometheusCollector("test")
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Navigation(200)
+	c.Navigation(200)
+	c.Navigation(503)
+	c.Reload()
+	c.Timeout("WaitResponse")
+	c.PageLoad(1500 * time.Millisecond)
+	c.Screenshot(20000)
+	c.CookiesRestored(3)
+
+	const want = `
+# HELP test_cookies_restored_total Number of restored cookies.
+# TYPE test_cookies_restored_total counter
+test_cookies_restored_total 3
+# HELP test_navigations_total Number of responses received for navigations by status code.
+# TYPE test_navigations_total counter
+test_navigations_total{status="200"} 2
+test_navigations_total{status="503"} 1
+# HELP test_reloads_total Number of page reloads to retry navigations.
+# TYPE test_reloads_total counter
+test_reloads_total 1
+# HELP test_timeouts_total Number of actions exceeded their timeout by action.
+# TYPE test_timeouts_total counter
+test_timeouts_total{action="WaitResponse"} 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"test_cookies_restored_total",
+		"test_navigations_total",
+		"test_reloads_total",
+		"test_timeouts_total",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(c, "test_page_load_duration_seconds", "test_screenshot_size_bytes"); n != 2 {
+		t.Fatalf("expected 2 histograms, got %d", n)
+	}
+}
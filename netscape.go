@@ -0,0 +1,141 @@
+package helper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+const (
+	netscapeHeader         = "# Netscape HTTP Cookie File"
+	netscapeHTTPOnlyPrefix = "#HttpOnly_"
+)
+
+// ReadNetscapeCookies reads cookies from r in Netscape cookies.txt format used by curl and wget.
+func ReadNetscapeCookies(r io.Reader) ([]*network.Cookie, error) {
+	cookies := make([]*network.Cookie, 0)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, netscapeHTTPOnlyPrefix)
+		if httpOnly {
+			line = strings.TrimPrefix(line, netscapeHTTPOnlyPrefix)
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("invalid cookie line %d: %d fields", n, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookie line %d: %v", n, err)
+		}
+		domain := fields[0]
+		if fields[1] == "TRUE" && !strings.HasPrefix(domain, ".") {
+			domain = "." + domain
+		}
+		c := &network.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   domain,
+			Path:     fields[2],
+			Expires:  float64(expires),
+			Size:     int64(len(fields[5]) + len(fields[6])),
+			HTTPOnly: httpOnly,
+			Secure:   fields[3] == "TRUE",
+			Priority: network.CookiePriorityMedium,
+		}
+		if expires == 0 {
+			c.Expires = -1
+			c.Session = true
+		}
+		cookies = append(cookies, c)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return cookies, nil
+}
+
+// WriteNetscapeCookies writes cookies to w in Netscape cookies.txt format used by curl and wget.
+//
+// Session cookies are written with expiration 0.
+func WriteNetscapeCookies(w io.Writer, cookies []*network.Cookie) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, netscapeHeader)
+	for _, c := range cookies {
+		domain := c.Domain
+		if c.HTTPOnly {
+			domain = netscapeHTTPOnlyPrefix + domain
+		}
+		var expires int64
+		if !c.Session && c.Expires > 0 {
+			expires = int64(c.Expires)
+		}
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			netscapeBool(strings.HasPrefix(c.Domain, ".")),
+			c.Path,
+			netscapeBool(c.Secure),
+			expires,
+			c.Name,
+			c.Value,
+		)
+	}
+	return bw.Flush()
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// NetscapeCookieStore is a CookieStore which stores cookies as Netscape cookies.txt file.
+//
+// The file is written atomically with mode 0600.
+type NetscapeCookieStore struct {
+	filename interface{}
+}
+
+// NewNetscapeCookieStore returns a new NetscapeCookieStore.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func NewNetscapeCookieStore(filename interface{}) *NetscapeCookieStore {
+	return &NetscapeCookieStore{filename: filename}
+}
+
+// Filename returns the name of the file.
+func (s *NetscapeCookieStore) Filename() string {
+	return toString(s.filename)
+}
+
+// Load implements CookieStore.
+func (s *NetscapeCookieStore) Load(ctx context.Context) ([]*network.Cookie, error) {
+	f, err := os.Open(s.Filename())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ReadNetscapeCookies(f)
+}
+
+// Save implements CookieStore.
+func (s *NetscapeCookieStore) Save(ctx context.Context, cookies []*network.Cookie) error {
+	return writeFileAtomic(s.Filename(), 0600, func(w io.Writer) error {
+		return WriteNetscapeCookies(w, cookies)
+	})
+}
@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestReadNetscapeCookies(t *testing.T) {
+	t.Parallel()
+	input := strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"# https://curl.se/docs/http-cookies.html",
+		"",
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\tval1",
+		".example.com\tTRUE\t/path\tTRUE\t1893456000\tpersistent\tval2",
+		"#HttpOnly_example.org\tFALSE\t/\tFALSE\t0\thttponly\tval3",
+	}, "\n")
+	got, err := ReadNetscapeCookies(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*network.Cookie{
+		{
+			Name:     "session",
+			Value:    "val1",
+			Domain:   "example.com",
+			Path:     "/",
+			Expires:  -1,
+			Size:     11,
+			Session:  true,
+			Priority: network.CookiePriorityMedium,
+		},
+		{
+			Name:     "persistent",
+			Value:    "val2",
+			Domain:   ".example.com",
+			Path:     "/path",
+			Expires:  1893456000,
+			Size:     14,
+			Secure:   true,
+			Priority: network.CookiePriorityMedium,
+		},
+		{
+			Name:     "httponly",
+			Value:    "val3",
+			Domain:   "example.org",
+			Path:     "/",
+			Expires:  -1,
+			Size:     12,
+			HTTPOnly: true,
+			Session:  true,
+			Priority: network.CookiePriorityMedium,
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("invalid length\nwant: %d, got: %d", len(want), len(got))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("\nwant[%d]: %+v\n got[%d]: %+v", i, want[i], i, got[i])
+		}
+	}
+
+	if _, err := ReadNetscapeCookies(strings.NewReader("example.com\tFALSE\t/\n")); err == nil {
+		t.Fatal("expected error for invalid line")
+	}
+}
+
+func TestWriteNetscapeCookies(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	cookies := []*network.Cookie{
+		{Name: "session", Value: "val1", Domain: "example.com", Path: "/", Expires: -1, Session: true},
+		{Name: "persistent", Value: "val2", Domain: ".example.com", Path: "/path", Expires: 1893456000, Secure: true},
+		{Name: "httponly", Value: "val3", Domain: "example.org", Path: "/", Expires: -1, HTTPOnly: true, Session: true},
+	}
+	if err := WriteNetscapeCookies(&buf, cookies); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\tval1",
+		".example.com\tTRUE\t/path\tTRUE\t1893456000\tpersistent\tval2",
+		"#HttpOnly_example.org\tFALSE\t/\tFALSE\t0\thttponly\tval3",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Fatalf("\nwant: %q\n got: %q", want, got)
+	}
+
+	got, err := ReadNetscapeCookies(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(cookies) {
+		t.Fatalf("invalid length\nwant: %d, got: %d", len(cookies), len(got))
+	}
+}
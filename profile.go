@@ -0,0 +1,341 @@
+package helper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	profileCookiesExt = ".jsonl"
+	profileMetaExt    = ".meta.json"
+	profileLockExt    = ".lock"
+)
+
+// ProfileManager manages named profiles of accounts stored in a directory.
+//
+// Each profile has a cookies file <name>.jsonl, a metadata file <name>.meta.json
+// and a lock file <name>.lock while it is used.
+type ProfileManager struct {
+	// StaleLockAge is the age after which lock files are considered left by crashed jobs and removed.
+	// Lock files are never removed if it is zero.
+	StaleLockAge time.Duration
+
+	dir     string
+	cookies *DirCookieStore
+	mu      sync.Mutex
+}
+
+// NewProfileManager returns a new ProfileManager which stores profiles in dir.
+//
+// dir is created when a profile is locked for the first time.
+func NewProfileManager(dir string) *ProfileManager {
+	return &ProfileManager{dir: dir, cookies: NewDirCookieStore(dir)}
+}
+
+// Profile returns the profile of name. It can be a profile which does not exist yet.
+func (m *ProfileManager) Profile(name string) (*Profile, error) {
+	store, err := m.cookies.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{m: m, name: name, store: store}, nil
+}
+
+// Profiles returns the sorted names of existing profiles.
+func (m *ProfileManager) Profiles() ([]string, error) {
+	infos, err := ioutil.ReadDir(m.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(name, profileMetaExt):
+			name = strings.TrimSuffix(name, profileMetaExt)
+		case strings.HasSuffix(name, profileCookiesExt):
+			name = strings.TrimSuffix(name, profileCookiesExt)
+		default:
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ProfileMeta is the metadata of a profile.
+type ProfileMeta struct {
+	Name      string            `json:"name"`
+	LastLogin time.Time         `json:"lastLogin,omitempty"`
+	LastUsed  time.Time         `json:"lastUsed,omitempty"`
+	Valid     bool              `json:"valid"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Profile is a named profile of an account.
+type Profile struct {
+	m     *ProfileManager
+	name  string
+	store *FileCookieStore
+}
+
+// Name returns the name of the profile.
+func (p *Profile) Name() string {
+	return p.name
+}
+
+// Store returns the CookieStore of the profile.
+func (p *Profile) Store() *FileCookieStore {
+	return p.store
+}
+
+func (p *Profile) path(ext string) string {
+	return filepath.Join(p.m.dir, p.name+ext)
+}
+
+// Meta returns the metadata of the profile.
+func (p *Profile) Meta() (*ProfileMeta, error) {
+	p.m.mu.Lock()
+	defer p.m.mu.Unlock()
+	return p.meta()
+}
+
+func (p *Profile) meta() (*ProfileMeta, error) {
+	meta := &ProfileMeta{Name: p.name}
+	b, err := ioutil.ReadFile(p.path(profileMetaExt))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return meta, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// UpdateMeta updates the metadata of the profile by update.
+func (p *Profile) UpdateMeta(update func(meta *ProfileMeta)) error {
+	p.m.mu.Lock()
+	defer p.m.mu.Unlock()
+	meta, err := p.meta()
+	if err != nil {
+		return err
+	}
+	update(meta)
+	meta.Name = p.name
+	return writeFileAtomic(p.path(profileMetaExt), 0600, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(meta)
+	})
+}
+
+// MarkLoggedIn records that the account of the profile has logged in now.
+func (p *Profile) MarkLoggedIn() error {
+	return p.UpdateMeta(func(meta *ProfileMeta) {
+		meta.LastLogin = time.Now()
+		meta.Valid = true
+	})
+}
+
+// Invalidate records that the session of the profile is not valid.
+func (p *Profile) Invalidate() error {
+	return p.UpdateMeta(func(meta *ProfileMeta) {
+		meta.Valid = false
+	})
+}
+
+// Lock creates the lock file of the profile and returns the function to remove it.
+//
+// The lock file holds the pid and a random token, and unlock removes it only if it still holds them.
+// While the profile is locked, the modification time of the lock file is refreshed every StaleLockAge/3,
+// so that the lock of a running job is never considered stale.
+// It returns *ProfileLockedError if the profile is used by another job.
+func (p *Profile) Lock() (unlock func() error, err error) {
+	if err := os.MkdirAll(p.m.dir, 0700); err != nil {
+		return nil, err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	owner := fmt.Sprintf("%d %s\n", os.Getpid(), token)
+	lockPath := p.path(profileLockExt)
+	for i := 0; ; i++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, err = io.WriteString(f, owner)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, err
+			}
+			return p.holdLock(lockPath, owner), nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		lockErr, content := p.lockError(lockPath)
+		if i > 0 || !p.stale(lockErr) {
+			return nil, lockErr
+		}
+		if err := p.takeOver(lockPath, token, content); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// stale reports whether the lock described by e is left by a crashed job.
+func (p *Profile) stale(e *ProfileLockedError) bool {
+	return p.m.StaleLockAge > 0 && !e.Since.IsZero() && time.Since(e.Since) >= p.m.StaleLockAge
+}
+
+// takeOver removes the stale lock file whose content is content.
+//
+// The lock file is renamed aside atomically and verified, so that a lock which has been taken over
+// by another job in the meantime is restored instead of removed.
+func (p *Profile) takeOver(lockPath, token, content string) error {
+	aside := filepath.Join(p.m.dir, "."+p.name+profileLockExt+"."+token)
+	if err := os.Rename(lockPath, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// another job has removed it
+			return nil
+		}
+		return err
+	}
+	e, asideContent := p.lockError(aside)
+	if asideContent != content || !p.stale(e) {
+		// restore the lock of another job unless yet another job has created a new one
+		if err := os.Link(aside, lockPath); err != nil && !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		os.Remove(aside)
+		e.Name = p.name
+		return e
+	}
+	log.Printf("Profile: remove stale lock name=%s pid=%d since=%s\n", p.name, e.PID, e.Since.Format(time.RFC3339))
+	return os.Remove(aside)
+}
+
+// holdLock refreshes the lock file while it is held and returns the function to remove it.
+func (p *Profile) holdLock(lockPath, owner string) func() error {
+	done := make(chan struct{})
+	if p.m.StaleLockAge > 0 {
+		ticker := time.NewTicker(p.m.StaleLockAge / 3)
+		go func() {
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if b, err := ioutil.ReadFile(lockPath); err != nil || string(b) != owner {
+						log.Printf("Profile: lock lost name=%s\n", p.name)
+						return
+					}
+					now := time.Now()
+					if err := os.Chtimes(lockPath, now, now); err != nil {
+						log.Printf("Profile: error=%v name=%s\n", err, p.name)
+					}
+				}
+			}
+		}()
+	}
+	var once sync.Once
+	return func() (err error) {
+		once.Do(func() {
+			close(done)
+			b, rerr := ioutil.ReadFile(lockPath)
+			if rerr != nil || string(b) != owner {
+				err = fmt.Errorf("lock of profile %s has been taken over", p.name)
+				return
+			}
+			err = os.Remove(lockPath)
+		})
+		return
+	}
+}
+
+// lockError returns the error of the lock file and its content.
+func (p *Profile) lockError(lockPath string) (*ProfileLockedError, string) {
+	e := &ProfileLockedError{Name: p.name}
+	if info, err := os.Stat(lockPath); err == nil {
+		e.Since = info.ModTime()
+	}
+	b, err := ioutil.ReadFile(lockPath)
+	if err != nil {
+		return e, ""
+	}
+	if fields := strings.Fields(string(b)); len(fields) > 0 {
+		e.PID, _ = strconv.Atoi(fields[0])
+	}
+	return e, string(b)
+}
+
+func randomToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// UseProfile is an action that runs actions with the cookies of profile.
+//
+// It locks the profile, restores its cookies, runs actions and saves the cookies if all actions succeed.
+// LastUsed of the metadata is updated after the actions run.
+func UseProfile(profile *Profile, actions ...chromedp.Action) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		unlock, err := profile.Lock()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := unlock(); err != nil {
+				log.Printf("UseProfile: error=%v name=%s\n", err, profile.name)
+			}
+		}()
+		log.Printf("UseProfile: name=%s\n", profile.name)
+
+		if err := RestoreCookiesFrom(profile.store).Do(ctx); err != nil {
+			return err
+		}
+		runErr := chromedp.Tasks(actions).Do(ctx)
+		if err := profile.UpdateMeta(func(meta *ProfileMeta) { meta.LastUsed = time.Now() }); err != nil && runErr == nil {
+			runErr = err
+		}
+		if runErr != nil {
+			return runErr
+		}
+		return SaveCookiesTo(profile.store).Do(ctx)
+	})
+}
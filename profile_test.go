@@ -0,0 +1,248 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func testProfileManager(t *testing.T) *ProfileManager {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return NewProfileManager(filepath.Join(dir, "profiles"))
+}
+
+func TestProfileManager(t *testing.T) {
+	t.Parallel()
+	m := testProfileManager(t)
+
+	names, err := m.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 {
+		t.Fatalf("expected no profiles, got %v", names)
+	}
+	if _, err := m.Profile("../escape"); err == nil {
+		t.Fatal("expected error for invalid profile name")
+	}
+
+	bob, err := m.Profile("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err := m.Profile("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := bob.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	if err := bob.Store().Save(context.Background(), testCookies()); err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.MarkLoggedIn(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err = m.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("%#v != %#v", names, want)
+	}
+
+	meta, err := alice.Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != "alice" || !meta.Valid || time.Since(meta.LastLogin) > time.Minute {
+		t.Fatalf("invalid metadata: %+v", meta)
+	}
+	if err := alice.Invalidate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.UpdateMeta(func(meta *ProfileMeta) { meta.Labels = map[string]string{"plan": "free"} }); err != nil {
+		t.Fatal(err)
+	}
+	meta, err = alice.Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Valid || meta.LastLogin.IsZero() || meta.Labels["plan"] != "free" {
+		t.Fatalf("invalid metadata: %+v", meta)
+	}
+
+	meta, err = bob.Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(meta, &ProfileMeta{Name: "bob"}) {
+		t.Fatalf("invalid metadata: %+v", meta)
+	}
+}
+
+func TestProfileLock(t *testing.T) {
+	t.Parallel()
+	m := testProfileManager(t)
+	p, err := m.Profile("account")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := p.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Lock()
+	var lockErr *ProfileLockedError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("expected ProfileLockedError, got %v", err)
+	}
+	if lockErr.Name != "account" || lockErr.PID != os.Getpid() || lockErr.Since.IsZero() {
+		t.Fatalf("invalid error: %+v", lockErr)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock must be idempotent: %v", err)
+	}
+
+	// stale locks are removed
+	unlock, err = p.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(p.path(profileLockExt), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Lock(); err == nil {
+		t.Fatal("expected error without StaleLockAge")
+	}
+	m.StaleLockAge = time.Minute
+	unlock2, err := p.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unlock2(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProfileLockOwner(t *testing.T) {
+	t.Parallel()
+	m := testProfileManager(t)
+	p, err := m.Profile("account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockPath := p.path(profileLockExt)
+
+	// unlock must not remove the lock of another job
+	unlock, err := p.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := "1 other\n"
+	if err := ioutil.WriteFile(lockPath, []byte(other), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := unlock(); err == nil {
+		t.Fatal("expected error of the lock taken over")
+	}
+	if b, err := ioutil.ReadFile(lockPath); err != nil || string(b) != other {
+		t.Fatalf("%#v != %#v", string(b), other)
+	}
+
+	// a fresh lock renamed aside by a racing job is restored
+	m.StaleLockAge = time.Minute
+	err = p.takeOver(lockPath, "racing", "1 stale\n")
+	var lockErr *ProfileLockedError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("expected ProfileLockedError, got %v", err)
+	}
+	if b, err := ioutil.ReadFile(lockPath); err != nil || string(b) != other {
+		t.Fatalf("%#v != %#v", string(b), other)
+	}
+	names, err := m.Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 {
+		t.Fatalf("%#v != %#v", names, []string{})
+	}
+}
+
+func TestProfileLockRefresh(t *testing.T) {
+	t.Parallel()
+	m := testProfileManager(t)
+	m.StaleLockAge = 300 * time.Millisecond
+	p, err := m.Profile("account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := p.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(p.path(profileLockExt), old, old); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	// the lock of the running job is refreshed and not taken over
+	if _, err := p.Lock(); err == nil {
+		t.Fatal("expected error of the refreshed lock")
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocateSeparate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	m := testProfileManager(t)
+	p, err := m.Profile("account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chromedp.Run(ctx, UseProfile(p, chromedp.Navigate(endpoint+"/cookies"))); err != nil {
+		t.Fatal(err)
+	}
+	cookies, err := p.Store().Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 2 {
+		t.Fatalf("invalid cookies: %+v", cookies)
+	}
+	meta, err := p.Meta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.LastUsed.IsZero() {
+		t.Fatalf("LastUsed is not updated: %+v", meta)
+	}
+	if _, err := os.Stat(p.path(profileLockExt)); !os.IsNotExist(err) {
+		t.Fatalf("lock file is not removed: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusCollector is a MetricsCollector which exposes the measurements as Prometheus metrics.
+//
+// It implements prometheus.Collector, so it can be registered to a prometheus.Registerer.
+type PrometheusCollector struct {
+	navigations     *prometheus.CounterVec
+	reloads         prometheus.Counter
+	timeouts        *prometheus.CounterVec
+	pageLoad        prometheus.Histogram
+	screenshotSize  prometheus.Histogram
+	cookiesRestored prometheus.Counter
+}
+
+var _ MetricsCollector = (*PrometheusCollector)(nil)
+var _ prometheus.Collector = (*PrometheusCollector)(nil)
+
+// NewPrometheusCollector returns a new PrometheusCollector whose metric names are prefixed by namespace.
+func NewPrometheusCollector(namespace string) *PrometheusCollector {
+	return &PrometheusCollector{
+		navigations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "navigations_total",
+			Help:      "Number of responses received for navigations by status code.",
+		}, []string{"status"}),
+		reloads: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reloads_total",
+			Help:      "Number of page reloads to retry navigations.",
+		}),
+		timeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "timeouts_total",
+			Help:      "Number of actions exceeded their timeout by action.",
+		}, []string{"action"}),
+		pageLoad: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "page_load_duration_seconds",
+			Help:      "Duration until pages are loaded.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+		}),
+		screenshotSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "screenshot_size_bytes",
+			Help:      "Size of saved screenshots.",
+			Buckets:   prometheus.ExponentialBuckets(16*1024, 2, 10),
+		}),
+		cookiesRestored: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cookies_restored_total",
+			Help:      "Number of restored cookies.",
+		}),
+	}
+}
+
+// Navigation implements MetricsCollector.
+func (c *PrometheusCollector) Navigation(status int64) {
+	c.navigations.WithLabelValues(strconv.FormatInt(status, 10)).Inc()
+}
+
+// Reload implements MetricsCollector.
+func (c *PrometheusCollector) Reload() {
+	c.reloads.Inc()
+}
+
+// Timeout implements MetricsCollector.
+func (c *PrometheusCollector) Timeout(action string) {
+	c.timeouts.WithLabelValues(action).Inc()
+}
+
+// PageLoad implements MetricsCollector.
+func (c *PrometheusCollector) PageLoad(d time.Duration) {
+	c.pageLoad.Observe(d.Seconds())
+}
+
+// Screenshot implements MetricsCollector.
+func (c *PrometheusCollector) Screenshot(bytes int) {
+	c.screenshotSize.Observe(float64(bytes))
+}
+
+// CookiesRestored implements MetricsCollector.
+func (c *PrometheusCollector) CookiesRestored(n int) {
+	c.cookiesRestored.Add(float64(n))
+}
+
+// Describe implements prometheus.Collector.
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.navigations.Describe(ch)
+	c.reloads.Describe(ch)
+	c.timeouts.Describe(ch)
+	c.pageLoad.Describe(ch)
+	c.screenshotSize.Describe(ch)
+	c.cookiesRestored.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.navigations.Collect(ch)
+	c.reloads.Collect(ch)
+	c.timeouts.Collect(ch)
+	c.pageLoad.Collect(ch)
+	c.screenshotSize.Collect(ch)
+	c.cookiesRestored.Collect(ch)
+}
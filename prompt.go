@@ -0,0 +1,211 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Prompt is a question to the user.
+type Prompt struct {
+	// Message is printed before waiting for input.
+	Message string
+	// Timeout is the duration to wait for input. It waits until the context is done if zero.
+	Timeout time.Duration
+	// Default is the answer used when the input is empty or timeout exceeded.
+	// Timeout returns *TimeoutError if it is empty.
+	Default string
+	// Choices are the acceptable answers. Any answer is acceptable if it is empty.
+	Choices []string
+	// IgnoreCase compares the answer with Choices case-insensitively.
+	IgnoreCase bool
+}
+
+// match returns the choice matching input.
+func (p *Prompt) match(input string) (string, bool) {
+	if len(p.Choices) == 0 {
+		return input, true
+	}
+	for _, c := range p.Choices {
+		if input == c || (p.IgnoreCase && strings.EqualFold(input, c)) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// ask prints the message and returns the input or the default answer.
+func (p *Prompt) ask(ctx context.Context, r io.Reader, message string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	fmt.Fprint(promptWriter(ctx), message)
+	input, err := readLine(ctx, r, p.Timeout)
+	if err == errReadTimeout {
+		if p.Default == "" {
+			metrics(ctx).Timeout("WaitInput")
+			return "", &TimeoutError{Action: "WaitInput", Timeout: p.Timeout}
+		}
+		log.Printf("WaitTerminalInput: timeout exceeded default=%s\n", p.Default)
+		return p.Default, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if input == "" && p.Default != "" {
+		return p.Default, nil
+	}
+	return input, nil
+}
+
+// Ask is an action that waits until input and sets the answer to answer.
+//
+// The answer is the matched choice if Choices is not empty.
+// It returns ErrCanceledByUser if the input is none of Choices.
+// Pass the same LineReader to sequential prompts so that a line entered after a timeout is not lost.
+func Ask(r io.Reader, p *Prompt, answer *string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		input, err := p.ask(ctx, r, p.Message)
+		if err != nil {
+			return err
+		}
+		res, ok := p.match(input)
+		if !ok {
+			log.Println("WaitTerminalInput: Canceled")
+			return ErrCanceledByUser
+		}
+		log.Println("WaitTerminalInput: Confirmed")
+		if answer != nil {
+			*answer = res
+		}
+		return nil
+	})
+}
+
+// Choose is an action that prints Choices of p as a numbered menu, waits until input and sets the index of the selected choice to selected.
+//
+// The choice can be selected by its number or its text. Default can also be a number or a text.
+// It returns ErrCanceledByUser if the input selects no choice.
+func Choose(r io.Reader, p *Prompt, selected *int) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var b strings.Builder
+		for i, c := range p.Choices {
+			fmt.Fprintf(&b, "%d) %s\n", i+1, c)
+		}
+		b.WriteString(p.Message)
+		input, err := p.ask(ctx, r, b.String())
+		if err != nil {
+			return err
+		}
+		i := p.index(input)
+		if i < 0 {
+			log.Println("WaitTerminalInput: Canceled")
+			return ErrCanceledByUser
+		}
+		log.Printf("WaitTerminalInput: Selected choice=%s\n", p.Choices[i])
+		if selected != nil {
+			*selected = i
+		}
+		return nil
+	})
+}
+
+// index returns the index of the choice selected by input, or -1.
+func (p *Prompt) index(input string) int {
+	if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(p.Choices) {
+		return n - 1
+	}
+	for i, c := range p.Choices {
+		if input == c || (p.IgnoreCase && strings.EqualFold(input, c)) {
+			return i
+		}
+	}
+	return -1
+}
+
+var errReadTimeout = errors.New("read timeout")
+
+// readDeadliner is a reader which can interrupt blocking reads, such as *os.File of a pipe or net.Conn.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// readLine reads a line from r until timeout exceeded or ctx done.
+//
+// os.Stdin is read by the shared LineReader returned by Stdin. Pass a LineReader to share other readers
+// between prompts, so that a line entered after a timeout is returned to the next prompt.
+// Other readers are read only up to the end of the line. If r supports read deadlines, the blocking read
+// is interrupted when ctx is done, otherwise the reading goroutine exits on the next input.
+func readLine(ctx context.Context, r io.Reader, timeout time.Duration) (string, error) {
+	if r == io.Reader(os.Stdin) {
+		r = Stdin()
+	}
+	rctx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		rctx, cancel = withTimeout(ctx, timeout)
+		defer cancel()
+	}
+	input, err := readLineContext(rctx, r)
+	if err == io.EOF {
+		return "", nil
+	}
+	if err != nil && ctx.Err() == nil && rctx.Err() != nil {
+		return "", errReadTimeout
+	}
+	return strings.TrimSpace(input), err
+}
+
+func readLineContext(ctx context.Context, r io.Reader) (string, error) {
+	if l, ok := r.(*LineReader); ok {
+		return l.ReadLine(ctx)
+	}
+	type result struct {
+		line string
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		line, err := readLineOnce(r)
+		ch <- result{line, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.line, res.err
+	case <-ctx.Done():
+		if d, ok := r.(readDeadliner); ok && d.SetReadDeadline(time.Now()) == nil {
+			<-ch
+			d.SetReadDeadline(time.Time{})
+		}
+		return "", ctx.Err()
+	}
+}
+
+// readLineOnce reads r byte by byte up to the end of the line, so that the rest of the input is left for the next read.
+func readLineOnce(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
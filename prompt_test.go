@@ -0,0 +1,180 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAsk(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		input   string
+		prompt  Prompt
+		want    string
+		wantErr error
+	}{
+		{name: "any answer", input: "hello\n", want: "hello"},
+		{name: "choice", input: "y\n", prompt: Prompt{Choices: []string{"Y", "y"}}, want: "y"},
+		{name: "case sensitive", input: "YES\n", prompt: Prompt{Choices: []string{"yes"}}, wantErr: ErrCanceledByUser},
+		{name: "ignore case", input: "YES\n", prompt: Prompt{Choices: []string{"yes", "no"}, IgnoreCase: true}, want: "yes"},
+		{name: "default", input: "\n", prompt: Prompt{Choices: []string{"yes", "no"}, Default: "no"}, want: "no"},
+		{name: "no default", input: "\n", prompt: Prompt{Choices: []string{"yes", "no"}}, wantErr: ErrCanceledByUser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			err := Ask(bytes.NewBufferString(tt.input), &tt.prompt, &got).Do(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%#v != %#v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAskTimeout(t *testing.T) {
+	t.Parallel()
+	r, w := io.Pipe()
+	defer w.Close()
+	c := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := WithClock(WithPromptWriter(context.Background(), ioutil.Discard), c)
+
+	ask := func(p *Prompt, answer *string) error {
+		done := make(chan error, 1)
+		go func() { done <- Ask(r, p, answer).Do(ctx) }()
+		c.BlockUntil(1)
+		c.Advance(p.Timeout)
+		return <-done
+	}
+
+	var got string
+	if err := ask(&Prompt{Timeout: time.Hour, Default: "no"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != "no" {
+		t.Fatalf("%#v != %#v", got, "no")
+	}
+
+	err := ask(&Prompt{Timeout: time.Hour}, &got)
+	var terr *TimeoutError
+	if !errors.As(err, &terr) || terr.Action != "WaitInput" {
+		t.Fatalf("expected TimeoutError, got %#v", err)
+	}
+}
+
+func TestChoose(t *testing.T) {
+	t.Parallel()
+	choices := []string{"Alice", "Bob", "Carol"}
+	tests := []struct {
+		name    string
+		input   string
+		prompt  Prompt
+		want    int
+		wantErr error
+	}{
+		{name: "number", input: "2\n", want: 1},
+		{name: "text", input: "Carol\n", want: 2},
+		{name: "ignore case", input: "carol\n", prompt: Prompt{IgnoreCase: true}, want: 2},
+		{name: "default number", input: "\n", prompt: Prompt{Default: "3"}, want: 2},
+		{name: "default text", input: "\n", prompt: Prompt{Default: "Bob"}, want: 1},
+		{name: "out of range", input: "4\n", want: -1, wantErr: ErrCanceledByUser},
+		{name: "unknown", input: "Dave\n", want: -1, wantErr: ErrCanceledByUser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prompt.Choices = choices
+			got := -1
+			err := Choose(bytes.NewBufferString(tt.input), &tt.prompt, &got).Do(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%#v != %#v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLineInterrupted(t *testing.T) {
+	t.Parallel()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := readLine(ctx, r, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("%#v != %#v", err, context.Canceled)
+	}
+
+	// the input must not be consumed by the goroutine of the canceled read
+	if _, err := io.WriteString(w, "next\n"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readLine(context.Background(), r, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "next" {
+		t.Fatalf("%#v != %#v", got, "next")
+	}
+}
+
+func TestAskTimeoutKeepsNextLine(t *testing.T) {
+	t.Parallel()
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+	r := NewLineReader(pr)
+	ctx := WithPromptWriter(context.Background(), ioutil.Discard)
+
+	var got string
+	if err := Ask(r, &Prompt{Timeout: 10 * time.Millisecond, Default: "default"}, &got).Do(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got != "default" {
+		t.Fatalf("%#v != %#v", got, "default")
+	}
+
+	// the line entered after the timeout must be the answer to the next prompt sharing the LineReader
+	if _, err := io.WriteString(pw, "first\nsecond\n"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"first", "second"} {
+		if err := Ask(r, &Prompt{Timeout: time.Second}, &got).Do(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%#v != %#v", got, want)
+		}
+	}
+}
+
+func TestReadLineLeavesRest(t *testing.T) {
+	t.Parallel()
+	r := bytes.NewBufferString("first\nsecond")
+	for _, want := range []string{"first", "second", ""} {
+		got, err := readLine(context.Background(), r, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%#v != %#v", got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// UnmatchedPolicy is the behaviour of HARReplayer for the requests which match no entry.
+type UnmatchedPolicy int
+
+const (
+	// UnmatchedFail fails the unmatched requests.
+	UnmatchedFail UnmatchedPolicy = iota
+	// UnmatchedPassthrough continues the unmatched requests to the network.
+	UnmatchedPassthrough
+	// UnmatchedNotFound responds to the unmatched requests with 404 Not Found.
+	UnmatchedNotFound
+)
+
+// replayIgnoredHeaders are the response headers which are not replayed
+// because the recorded content is already decoded.
+var replayIgnoredHeaders = map[string]bool{
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+}
+
+// HARReplayer is a RouteHandler which serves requests from the entries of a HAR.
+//
+// Requests are matched by method and url without fragment.
+// If several entries match a request, they are served in recorded order and the last one is repeated.
+type HARReplayer struct {
+	unmatched UnmatchedPolicy
+	mu        sync.Mutex
+	entries   map[string][]*HAREntry
+	served    map[string]int
+}
+
+// NewHARReplayer returns a new HARReplayer serving the entries of h.
+func NewHARReplayer(h *HAR, unmatched UnmatchedPolicy) *HARReplayer {
+	r := &HARReplayer{
+		unmatched: unmatched,
+		entries:   make(map[string][]*HAREntry),
+		served:    make(map[string]int),
+	}
+	for _, e := range h.Log.Entries {
+		// failed requests have no response to replay
+		if e.Request == nil || e.Response == nil || e.Response.Status == 0 {
+			continue
+		}
+		key := replayKey(e.Request.Method, e.Request.URL)
+		r.entries[key] = append(r.entries[key], e)
+	}
+	return r
+}
+
+// Lookup returns the entry which will be served for the request.
+func (r *HARReplayer) Lookup(method, urlstr string) (*HAREntry, bool) {
+	key := replayKey(method, urlstr)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.entries[key]
+	if len(entries) == 0 {
+		return nil, false
+	}
+	i := r.served[key]
+	if i >= len(entries) {
+		i = len(entries) - 1
+	}
+	r.served[key] = i + 1
+	return entries[i], true
+}
+
+// ServeRequest implements RouteHandler.
+func (r *HARReplayer) ServeRequest(req *InterceptedRequest) error {
+	e, ok := r.Lookup(req.Request.Method, req.Request.URL)
+	if !ok {
+		log.Printf("HARReplayer: unmatched method=%s url=%s\n", req.Request.Method, req.Request.URL)
+		switch r.unmatched {
+		case UnmatchedPassthrough:
+			return req.Continue()
+		case UnmatchedNotFound:
+			return req.Fulfill(http.StatusNotFound, nil, nil)
+		default:
+			return req.Abort(network.ErrorReasonFailed)
+		}
+	}
+	body, err := e.Response.Content.Body()
+	if err != nil {
+		return err
+	}
+	header := make(http.Header)
+	for _, h := range e.Response.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		if replayIgnoredHeaders[name] {
+			continue
+		}
+		header.Add(name, h.Value)
+	}
+	return req.Fulfill(e.Response.Status, header, body)
+}
+
+// LoadHAR loads HAR from json file.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func LoadHAR(filename interface{}) (*HAR, error) {
+	f, err := os.Open(toString(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var h HAR
+	if err := json.NewDecoder(f).Decode(&h); err != nil {
+		return nil, err
+	}
+	if h.Log == nil {
+		h.Log = &HARLog{}
+	}
+	return &h, nil
+}
+
+// ReplayHAR is an action that serves requests from the entries of HAR file.
+//
+// It runs InterceptRequests, so use NewHARReplayer with Router or ResourceBlocker.Next to combine it with other handlers.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func ReplayHAR(filename interface{}, unmatched UnmatchedPolicy) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		h, err := LoadHAR(filename)
+		if err != nil {
+			return err
+		}
+		log.Printf("ReplayHAR: entries=%d\n", len(h.Log.Entries))
+		return InterceptRequests(NewHARReplayer(h, unmatched)).Do(ctx)
+	})
+}
+
+func replayKey(method, urlstr string) string {
+	if i := strings.IndexByte(urlstr, '#'); i >= 0 {
+		urlstr = urlstr[:i]
+	}
+	return method + " " + urlstr
+}
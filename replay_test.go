@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func testHAR() *HAR {
+	entry := func(method, urlstr string, status int64, text string) *HAREntry {
+		return &HAREntry{
+			Request: &HARRequest{Method: method, URL: urlstr},
+			Response: &HARResponse{
+				Status: status,
+				Headers: []*HARNameValue{
+					{Name: "content-type", Value: "text/html"},
+					{Name: "content-encoding", Value: "gzip"},
+				},
+				Content: &HARContent{MimeType: "text/html", Text: text},
+			},
+		}
+	}
+	return &HAR{
+		Log: &HARLog{
+			Version: "1.2",
+			Entries: []*HAREntry{
+				entry("GET", "https://replay.invalid/", 200, `<html><body><p id="text">replayed</p></body></html>`),
+				entry("GET", "https://replay.invalid/counter", 200, "1"),
+				entry("GET", "https://replay.invalid/counter", 200, "2"),
+				entry("GET", "https://replay.invalid/failed", 0, ""),
+			},
+		},
+	}
+}
+
+func TestHARReplayerLookup(t *testing.T) {
+	t.Parallel()
+	r := NewHARReplayer(testHAR(), UnmatchedFail)
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		want   string
+		ok     bool
+	}{
+		{name: "first", method: "GET", url: "https://replay.invalid/counter", want: "1", ok: true},
+		{name: "second", method: "GET", url: "https://replay.invalid/counter", want: "2", ok: true},
+		{name: "repeat last", method: "GET", url: "https://replay.invalid/counter#fragment", want: "2", ok: true},
+		{name: "method not matched", method: "POST", url: "https://replay.invalid/counter", ok: false},
+		{name: "failed entry", method: "GET", url: "https://replay.invalid/failed", ok: false},
+		{name: "url not matched", method: "GET", url: "https://replay.invalid/unknown", ok: false},
+	}
+	// the subtests run sequentially because the replayer serves the entries in order
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := r.Lookup(tt.method, tt.url)
+			if ok != tt.ok {
+				t.Fatalf("%#v != %#v", ok, tt.ok)
+			}
+			if ok && e.Response.Content.Text != tt.want {
+				t.Fatalf("%#v != %#v", e.Response.Content.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHAR(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	harpath := filepath.Join(dir, "test.har")
+	if err := ioutil.WriteFile(harpath, []byte(`{"log":{"version":"1.2","entries":[{"request":{"method":"GET","url":"https://example.com/"}}]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := LoadHAR(harpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Log.Entries) != 1 || h.Log.Entries[0].Request.URL != "https://example.com/" {
+		t.Fatalf("unexpected entries: %+v", h.Log.Entries)
+	}
+
+	if _, err := LoadHAR(filepath.Join(dir, "not-found.har")); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+}
+
+func TestReplayHAR(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+
+	var got string
+	tasks := chromedp.Tasks{
+		network.Enable(),
+		EnableLifeCycleEvents(),
+		InterceptRequests(NewHARReplayer(testHAR(), UnmatchedNotFound)),
+		Navigate("https://replay.invalid/", 5*time.Second),
+		chromedp.Text("#text", &got),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	const want = "replayed"
+	if got != want {
+		t.Fatalf("expected text to be %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,404 @@
+package helper
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// URLMatcher reports whether the request url matches.
+type URLMatcher func(urlstr string) bool
+
+// MatchAll returns a URLMatcher which matches all urls.
+func MatchAll() URLMatcher {
+	return func(string) bool { return true }
+}
+
+// MatchPrefix returns a URLMatcher which matches urls starting with prefix.
+//
+// prefix can be specified by string, string pointer or fmt.Stringer.
+func MatchPrefix(prefix interface{}) URLMatcher {
+	return func(urlstr string) bool {
+		return strings.HasPrefix(urlstr, toString(prefix))
+	}
+}
+
+// MatchDomain returns a URLMatcher which matches urls whose host is domain or its subdomain.
+func MatchDomain(domain string) URLMatcher {
+	return func(urlstr string) bool {
+		u, err := url.Parse(urlstr)
+		if err != nil {
+			return false
+		}
+		host := u.Hostname()
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+}
+
+// MatchRegexp returns a URLMatcher which matches urls matching re.
+func MatchRegexp(re *regexp.Regexp) URLMatcher {
+	return re.MatchString
+}
+
+// InterceptedRequest is a request paused by request interception.
+//
+// Handlers may modify URL, Method, Headers and PostData of Request before calling Continue.
+type InterceptedRequest struct {
+	*fetch.EventRequestPaused
+
+	ctx     context.Context
+	orig    network.Request
+	mu      sync.Mutex
+	handled bool
+}
+
+func newInterceptedRequest(ctx context.Context, ev *fetch.EventRequestPaused) *InterceptedRequest {
+	orig := *ev.Request
+	orig.Headers = make(network.Headers, len(ev.Request.Headers))
+	for k, v := range ev.Request.Headers {
+		orig.Headers[k] = v
+	}
+	return &InterceptedRequest{
+		EventRequestPaused: ev,
+		ctx:                ctx,
+		orig:               orig,
+	}
+}
+
+// Context returns the context of the browser tab which sent the request.
+func (r *InterceptedRequest) Context() context.Context {
+	return r.ctx
+}
+
+// HTTPRequest returns the request as *http.Request.
+func (r *InterceptedRequest) HTTPRequest() (*http.Request, error) {
+	req, err := http.NewRequestWithContext(r.ctx, r.Request.Method, r.Request.URL, strings.NewReader(r.Request.PostData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = toHTTPHeader(r.Request.Headers)
+	return req, nil
+}
+
+// Continue continues the request with the modifications made to Request.
+func (r *InterceptedRequest) Continue() error {
+	p := fetch.ContinueRequest(r.RequestID)
+	if r.Request.URL != r.orig.URL {
+		p = p.WithURL(r.Request.URL)
+	}
+	if r.Request.Method != r.orig.Method {
+		p = p.WithMethod(r.Request.Method)
+	}
+	if r.Request.PostData != r.orig.PostData {
+		p = p.WithPostData(r.Request.PostData)
+	}
+	if !reflect.DeepEqual(r.Request.Headers, r.orig.Headers) {
+		p = p.WithHeaders(toHeaderEntries(toHTTPHeader(r.Request.Headers)))
+	}
+	return r.resolve(func() error { return p.Do(r.ctx) })
+}
+
+// Fulfill responds to the request with the canned response.
+func (r *InterceptedRequest) Fulfill(status int64, header http.Header, body []byte) error {
+	return r.resolve(func() error {
+		return fetch.FulfillRequest(r.RequestID, status).
+			WithResponseHeaders(toHeaderEntries(header)).
+			WithBody(base64.StdEncoding.EncodeToString(body)).
+			Do(r.ctx)
+	})
+}
+
+// Abort fails the request with reason.
+func (r *InterceptedRequest) Abort(reason network.ErrorReason) error {
+	return r.resolve(func() error {
+		return fetch.FailRequest(r.RequestID, reason).Do(r.ctx)
+	})
+}
+
+// resolve calls call unless the request has been handled, and marks it as handled if call succeeds,
+// so that a request whose resolution failed can still be continued or failed.
+func (r *InterceptedRequest) resolve(call func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.handled {
+		return nil
+	}
+	if err := call(); err != nil {
+		return err
+	}
+	r.handled = true
+	return nil
+}
+
+// RouteHandler handles an intercepted request.
+//
+// The request is continued unmodified if the handler returns without resolving it.
+type RouteHandler interface {
+	ServeRequest(req *InterceptedRequest) error
+}
+
+// RouteHandlerFunc is an adapter to allow the use of ordinary functions as RouteHandler.
+type RouteHandlerFunc func(req *InterceptedRequest) error
+
+// ServeRequest calls f(req).
+func (f RouteHandlerFunc) ServeRequest(req *InterceptedRequest) error {
+	return f(req)
+}
+
+// HTTPHandler returns a RouteHandler which fulfills requests with the response written by h.
+func HTTPHandler(h http.Handler) RouteHandler {
+	return RouteHandlerFunc(func(req *InterceptedRequest) error {
+		hreq, err := req.HTTPRequest()
+		if err != nil {
+			return err
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, hreq)
+		res := rec.Result()
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+		return req.Fulfill(int64(res.StatusCode), res.Header, body)
+	})
+}
+
+// StaticResponse returns a RouteHandler which fulfills requests with the canned response.
+func StaticResponse(status int64, header http.Header, body []byte) RouteHandler {
+	return RouteHandlerFunc(func(req *InterceptedRequest) error {
+		return req.Fulfill(status, header, body)
+	})
+}
+
+// AbortRequest returns a RouteHandler which fails requests with reason.
+func AbortRequest(reason network.ErrorReason) RouteHandler {
+	return RouteHandlerFunc(func(req *InterceptedRequest) error {
+		return req.Abort(reason)
+	})
+}
+
+// RewriteURL returns a RouteHandler which continues requests with the url returned by rewrite.
+func RewriteURL(rewrite func(urlstr string) string) RouteHandler {
+	return RouteHandlerFunc(func(req *InterceptedRequest) error {
+		req.Request.URL = rewrite(req.Request.URL)
+		return req.Continue()
+	})
+}
+
+// ModifyHeaders returns a RouteHandler which continues requests with the headers modified by modify.
+func ModifyHeaders(modify func(header http.Header)) RouteHandler {
+	return RouteHandlerFunc(func(req *InterceptedRequest) error {
+		header := toHTTPHeader(req.Request.Headers)
+		modify(header)
+		req.Request.Headers = make(network.Headers, len(header))
+		for k, vs := range header {
+			req.Request.Headers[k] = strings.Join(vs, "\n")
+		}
+		return req.Continue()
+	})
+}
+
+type route struct {
+	match   URLMatcher
+	handler RouteHandler
+}
+
+// Router is a RouteHandler which dispatches requests to the handler of the first matched route.
+//
+// Requests which match no route are continued unmodified.
+type Router struct {
+	mu     sync.RWMutex
+	routes []route
+}
+
+// NewRouter returns a new Router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers h for requests matching m.
+func (rt *Router) Handle(m URLMatcher, h RouteHandler) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.routes = append(rt.routes, route{match: m, handler: h})
+}
+
+// HandleFunc registers f for requests matching m.
+func (rt *Router) HandleFunc(m URLMatcher, f func(req *InterceptedRequest) error) {
+	rt.Handle(m, RouteHandlerFunc(f))
+}
+
+// Handler returns the handler of the first route matching urlstr.
+func (rt *Router) Handler(urlstr string) (RouteHandler, bool) {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	for _, r := range rt.routes {
+		if r.match(urlstr) {
+			return r.handler, true
+		}
+	}
+	return nil, false
+}
+
+// ServeRequest implements RouteHandler.
+func (rt *Router) ServeRequest(req *InterceptedRequest) error {
+	h, ok := rt.Handler(req.Request.URL)
+	if !ok {
+		return req.Continue()
+	}
+	return h.ServeRequest(req)
+}
+
+// interceptedTargets holds the interception running in each browser tab.
+var interceptedTargets sync.Map
+
+// interception is a request interception running in a browser tab.
+type interception struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stopped is true if fetch is disabled by the action which stops the interception.
+	stopped bool
+	// done is closed when the interception is removed from interceptedTargets.
+	done chan struct{}
+}
+
+// stop stops the listener of the interception and waits until it is removed.
+func (it *interception) stop() {
+	it.stopped = true
+	it.cancel()
+	<-it.done
+}
+
+// InterceptRequests is an action that enables request interception and passes all requests to h.
+//
+// The interception lasts until the context passed to chromedp.Run is done or StopInterception runs,
+// and then fetch.Disable is called so that no request of the tab stays paused.
+// Requests are continued unmodified if h neither resolves them nor fails, and failed if continuing them fails.
+//
+// Only one InterceptRequests can run in a browser tab, because every listener would resolve the same requests.
+// It returns an error if another one is running. Compose handlers by Router or ResourceBlocker.Next instead.
+func InterceptRequests(h RouteHandler) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		lctx, cancel := context.WithCancel(ctx)
+		it := &interception{ctx: lctx, cancel: cancel, done: make(chan struct{})}
+		if c := chromedp.FromContext(ctx); c != nil && c.Target != nil {
+			if err := registerInterception(ctx, c.Target, it); err != nil {
+				cancel()
+				return err
+			}
+			go func() {
+				<-lctx.Done()
+				if !it.stopped {
+					// the context of the run is done, so disable fetch by a context which is not canceled
+					dctx, cancel := withTimeout(context.WithoutCancel(ctx), 5*time.Second)
+					if err := fetch.Disable().Do(dctx); err != nil {
+						log.Printf("InterceptRequests: error=%v\n", err)
+					}
+					cancel()
+				}
+				interceptedTargets.CompareAndDelete(c.Target, it)
+				close(it.done)
+			}()
+		} else {
+			close(it.done)
+		}
+		chromedp.ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*fetch.EventRequestPaused)
+			if !ok {
+				return
+			}
+			// handle in another goroutine because actions cannot be run in the listener
+			go func() {
+				req := newInterceptedRequest(ctx, e)
+				if err := h.ServeRequest(req); err != nil {
+					log.Printf("InterceptRequests: error=%v url=%s\n", err, e.Request.URL)
+				}
+				if err := req.Continue(); err != nil {
+					log.Printf("InterceptRequests: error=%v url=%s\n", err, e.Request.URL)
+					// fail the request not to leave it paused forever
+					if err := req.Abort(network.ErrorReasonFailed); err != nil {
+						log.Printf("InterceptRequests: error=%v url=%s\n", err, e.Request.URL)
+					}
+				}
+			}()
+		})
+		if err := fetch.Enable().Do(ctx); err != nil {
+			it.stop()
+			return err
+		}
+		return nil
+	})
+}
+
+// registerInterception registers it as the interception of t.
+// It waits for the previous interception to be removed if its context is done.
+func registerInterception(ctx context.Context, t *chromedp.Target, it *interception) error {
+	for {
+		v, loaded := interceptedTargets.LoadOrStore(t, it)
+		if !loaded {
+			return nil
+		}
+		prev := v.(*interception)
+		if prev.ctx.Err() == nil {
+			return errors.New("requests are already intercepted in this tab; compose handlers by Router or ResourceBlocker.Next")
+		}
+		select {
+		case <-prev.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// StopInterception is an action that stops the request interception of InterceptRequests in the current browser tab.
+//
+// Another InterceptRequests can run in the tab after it.
+func StopInterception() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if c := chromedp.FromContext(ctx); c != nil && c.Target != nil {
+			if v, ok := interceptedTargets.Load(c.Target); ok {
+				v.(*interception).stop()
+			}
+		}
+		return fetch.Disable().Do(ctx)
+	})
+}
+
+func toHTTPHeader(headers network.Headers) http.Header {
+	header := make(http.Header, len(headers))
+	for k, v := range headers {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		for _, line := range strings.Split(s, "\n") {
+			header.Add(k, line)
+		}
+	}
+	return header
+}
+
+func toHeaderEntries(header http.Header) []*fetch.HeaderEntry {
+	entries := make([]*fetch.HeaderEntry, 0, len(header))
+	for k, vs := range header {
+		for _, v := range vs {
+			entries = append(entries, &fetch.HeaderEntry{Name: k, Value: v})
+		}
+	}
+	return entries
+}
@@ -0,0 +1,277 @@
+package helper
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func TestURLMatcher(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		matcher URLMatcher
+		url     string
+		want    bool
+	}{
+		{
+			name:    "all",
+			matcher: MatchAll(),
+			url:     "https://example.com/",
+			want:    true,
+		},
+		{
+			name:    "prefix matched",
+			matcher: MatchPrefix("https://example.com/api/"),
+			url:     "https://example.com/api/users",
+			want:    true,
+		},
+		{
+			name:    "prefix not matched",
+			matcher: MatchPrefix("https://example.com/api/"),
+			url:     "https://example.com/",
+			want:    false,
+		},
+		{
+			name:    "domain matched",
+			matcher: MatchDomain("example.com"),
+			url:     "https://example.com/",
+			want:    true,
+		},
+		{
+			name:    "subdomain matched",
+			matcher: MatchDomain("example.com"),
+			url:     "https://www.example.com/",
+			want:    true,
+		},
+		{
+			name:    "domain not matched",
+			matcher: MatchDomain("example.com"),
+			url:     "https://badexample.com/",
+			want:    false,
+		},
+		{
+			name:    "regexp matched",
+			matcher: MatchRegexp(regexp.MustCompile(`\.png$`)),
+			url:     "https://example.com/image.png",
+			want:    true,
+		},
+		{
+			name:    "regexp not matched",
+			matcher: MatchRegexp(regexp.MustCompile(`\.png$`)),
+			url:     "https://example.com/image.jpg",
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.matcher(tt.url); got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterHandler(t *testing.T) {
+	t.Parallel()
+	var got string
+	handler := func(name string) RouteHandler {
+		return RouteHandlerFunc(func(*InterceptedRequest) error {
+			got = name
+			return nil
+		})
+	}
+	rt := NewRouter()
+	rt.Handle(MatchPrefix("https://example.com/api/"), handler("api"))
+	rt.Handle(MatchPrefix("https://example.com/"), handler("root"))
+
+	tests := []struct {
+		url  string
+		want string
+		ok   bool
+	}{
+		{url: "https://example.com/api/users", want: "api", ok: true},
+		{url: "https://example.com/index.html", want: "root", ok: true},
+		{url: "https://example.org/", ok: false},
+	}
+	for _, tt := range tests {
+		got = ""
+		h, ok := rt.Handler(tt.url)
+		if ok != tt.ok {
+			t.Fatalf("%s: expected ok to be %v", tt.url, tt.ok)
+		}
+		if ok {
+			h.ServeRequest(nil)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: %#v != %#v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestInterceptedRequestHTTPRequest(t *testing.T) {
+	t.Parallel()
+	req := newInterceptedRequest(context.Background(), &fetch.EventRequestPaused{
+		RequestID: "1",
+		Request: &network.Request{
+			URL:      "https://example.com/api",
+			Method:   http.MethodPost,
+			Headers:  network.Headers{"Content-Type": "application/json", "Accept": "a\nb"},
+			PostData: `{"key":"value"}`,
+		},
+	})
+	hreq, err := req.HTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hreq.Method != http.MethodPost || hreq.URL.String() != "https://example.com/api" {
+		t.Fatalf("unexpected request: %s %s", hreq.Method, hreq.URL)
+	}
+	wantHeader := http.Header{"Content-Type": {"application/json"}, "Accept": {"a", "b"}}
+	if !reflect.DeepEqual(hreq.Header, wantHeader) {
+		t.Fatalf("%#v != %#v", hreq.Header, wantHeader)
+	}
+	body, err := ioutil.ReadAll(hreq.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"key":"value"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	// modifying request must not change the original
+	req.Request.Headers["Accept"] = "c"
+	if req.orig.Headers["Accept"] != "a\nb" {
+		t.Fatal("expected original headers not to be modified")
+	}
+}
+
+func TestInterceptRequests(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	rt := NewRouter()
+	rt.Handle(MatchPrefix(endpoint+"/stub"), HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<html><body><p id="text">stubbed</p></body></html>`)
+	})))
+
+	var got string
+	tasks := chromedp.Tasks{
+		network.Enable(),
+		EnableLifeCycleEvents(),
+		InterceptRequests(rt),
+		Navigate(endpoint+"/stub", 5*time.Second),
+		chromedp.Text("#text", &got),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	const want = "stubbed"
+	if got != want {
+		t.Fatalf("expected text to be %q, got %q", want, got)
+	}
+}
+
+func TestInterceptedRequestResolveError(t *testing.T) {
+	t.Parallel()
+	// the CDP calls fail without a browser
+	req := newInterceptedRequest(context.Background(), &fetch.EventRequestPaused{
+		RequestID: "1",
+		Request:   &network.Request{URL: "https://example.com", Method: http.MethodGet},
+	})
+	if err := req.Fulfill(http.StatusOK, nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if req.handled {
+		t.Fatal("expected request not to be handled after error")
+	}
+	if err := req.Continue(); err == nil {
+		t.Fatal("expected error")
+	}
+	req.handled = true
+	if err := req.Abort(network.ErrorReasonFailed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterceptRequestsTwice(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+
+	if err := chromedp.Run(ctx, InterceptRequests(NewRouter())); err != nil {
+		t.Fatal(err)
+	}
+	if err := chromedp.Run(ctx, InterceptRequests(NewRouter())); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRegisterInterception(t *testing.T) {
+	t.Parallel()
+	target := &chromedp.Target{}
+	ctx, cancel := context.WithCancel(context.Background())
+	first := &interception{ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	if err := registerInterception(context.Background(), target, first); err != nil {
+		t.Fatal(err)
+	}
+	second := &interception{ctx: context.Background(), done: make(chan struct{})}
+	if err := registerInterception(context.Background(), target, second); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// the next interception waits until the previous one whose context is done is removed
+	cancel()
+	go func() {
+		interceptedTargets.CompareAndDelete(target, first)
+		close(first.done)
+	}()
+	if err := registerInterception(context.Background(), target, second); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := interceptedTargets.Load(target); v != second {
+		t.Fatalf("%#v != %#v", v, second)
+	}
+	interceptedTargets.Delete(target)
+}
+
+func TestInterceptRequestsCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	rctx, rcancel := context.WithCancel(ctx)
+	if err := chromedp.Run(rctx, InterceptRequests(NewRouter())); err != nil {
+		t.Fatal(err)
+	}
+	rcancel()
+
+	// requests of the tab must not stay paused after the context of the interception is done
+	var got string
+	tctx, tcancel := context.WithTimeout(ctx, 10*time.Second)
+	defer tcancel()
+	if err := chromedp.Run(tctx, Navigate(endpoint+"/login", 5*time.Second), chromedp.Text("body", &got)); err != nil {
+		t.Fatal(err)
+	}
+	if got != "ok" {
+		t.Fatalf("%#v != %#v", got, "ok")
+	}
+
+	// another interception can run in the tab after stopping
+	if err := chromedp.Run(tctx, InterceptRequests(NewRouter()), StopInterception(), InterceptRequests(NewRouter())); err != nil {
+		t.Fatal(err)
+	}
+}
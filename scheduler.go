@@ -0,0 +1,240 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// MissedRunPolicy decides what a Scheduler does with runs which could not start on time.
+type MissedRunPolicy int
+
+const (
+	// SkipMissed records missed runs in the history and waits for the next activation.
+	SkipMissed MissedRunPolicy = iota
+	// RunMissedOnce runs the job once as soon as possible for missed runs.
+	RunMissedOnce
+)
+
+// RunStatus is the status of a run of a scheduled job.
+type RunStatus string
+
+const (
+	// RunSucceeded is the status of a run whose tasks succeeded.
+	RunSucceeded RunStatus = "succeeded"
+	// RunFailed is the status of a run whose tasks failed.
+	RunFailed RunStatus = "failed"
+	// RunSkipped is the status of a run skipped because the previous run was still running.
+	RunSkipped RunStatus = "skipped"
+	// RunMissed is the status of a run which could not start on time.
+	RunMissed RunStatus = "missed"
+)
+
+// Job is a job scheduled by Scheduler.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Tasks    chromedp.Tasks
+	// NewTab runs the tasks in a fresh tab each time. The tab of the scheduler context is reused if false.
+	NewTab bool
+	// Jitter delays each run by a random duration in [0, Jitter).
+	Jitter time.Duration
+	// SkipIfRunning skips a run if the previous run is still running. Runs overlap if false.
+	SkipIfRunning bool
+	// Missed is the policy of runs which could not start on time.
+	Missed MissedRunPolicy
+	// MissedAfter is the delay after which a run is considered missed. The default is 1 minute.
+	MissedAfter time.Duration
+	// Timeout is the timeout of each run. There is no timeout if it is zero.
+	Timeout time.Duration
+}
+
+func (j *Job) missedAfter() time.Duration {
+	if j.MissedAfter <= 0 {
+		return time.Minute
+	}
+	return j.MissedAfter
+}
+
+// Run is a record of a run of a scheduled job.
+type Run struct {
+	Job       string
+	Scheduled time.Time
+	Started   time.Time
+	Finished  time.Time
+	Status    RunStatus
+	Err       error
+}
+
+// Scheduler runs jobs on their schedules. The zero value is ready to use.
+type Scheduler struct {
+	// HistoryLimit is the number of runs kept in the history. The default is 100.
+	HistoryLimit int
+
+	mu      sync.Mutex
+	jobs    []*Job
+	history []Run
+
+	// run runs the tasks instead of chromedp.Run if not nil, which is replaced in tests.
+	run func(ctx context.Context, action chromedp.Action) error
+}
+
+// NewScheduler returns a new Scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) runTasks(ctx context.Context, action chromedp.Action) error {
+	if s.run != nil {
+		return s.run(ctx, action)
+	}
+	return chromedp.Run(ctx, action)
+}
+
+// Add adds j to s. Jobs added after Run starts are not run.
+func (s *Scheduler) Add(j *Job) error {
+	if j.Name == "" {
+		return errors.New("job name is empty")
+	}
+	if j.Schedule == nil {
+		return errors.New("job schedule is nil name=" + j.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.jobs {
+		if v.Name == j.Name {
+			return errors.New("duplicate job name=" + j.Name)
+		}
+	}
+	s.jobs = append(s.jobs, j)
+	return nil
+}
+
+// History returns the runs recorded so far, oldest first.
+func (s *Scheduler) History() []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Run(nil), s.history...)
+}
+
+func (s *Scheduler) record(r Run) {
+	log.Printf("Scheduler: job=%s status=%s scheduled=%s err=%v\n", r.Job, r.Status, r.Scheduled.Format(time.RFC3339), r.Err)
+	limit := s.HistoryLimit
+	if limit <= 0 {
+		limit = 100
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, r)
+	if n := len(s.history) - limit; n > 0 {
+		s.history = append(s.history[:0], s.history[n:]...)
+	}
+}
+
+// Run runs the jobs on their schedules until ctx is done, and returns ctx.Err() after the running runs finish.
+//
+// ctx must be a chromedp context. The times are taken from the Clock of ctx.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	jobs := append([]*Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *Job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// loop runs j on its schedule until ctx is done.
+func (s *Scheduler) loop(ctx context.Context, j *Job) {
+	c := clock(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	var running int32
+
+	next := j.Schedule.Next(c.Now())
+	for !next.IsZero() {
+		var jitter time.Duration
+		if j.Jitter > 0 {
+			jitter = time.Duration(rand.Int63n(int64(j.Jitter)))
+		}
+		timer := c.NewTimer(next.Add(jitter).Sub(c.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		now := c.Now()
+		due := []time.Time{next}
+		for n := j.Schedule.Next(next); !n.IsZero() && !n.After(now); n = j.Schedule.Next(n) {
+			due = append(due, n)
+		}
+		next = j.Schedule.Next(due[len(due)-1])
+
+		scheduled := due[len(due)-1]
+		if now.Sub(scheduled)-jitter > j.missedAfter() && j.Missed == SkipMissed {
+			for _, t := range due {
+				s.record(Run{Job: j.Name, Scheduled: t, Status: RunMissed})
+			}
+			continue
+		}
+		for _, t := range due[:len(due)-1] {
+			s.record(Run{Job: j.Name, Scheduled: t, Status: RunMissed})
+		}
+
+		if j.SkipIfRunning && atomic.LoadInt32(&running) > 0 {
+			s.record(Run{Job: j.Name, Scheduled: scheduled, Status: RunSkipped})
+			continue
+		}
+		atomic.AddInt32(&running, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer atomic.AddInt32(&running, -1)
+			s.execute(ctx, j, scheduled)
+		}()
+	}
+}
+
+// execute runs the tasks of j and records the run.
+func (s *Scheduler) execute(ctx context.Context, j *Job, scheduled time.Time) {
+	c := clock(ctx)
+	r := Run{Job: j.Name, Scheduled: scheduled, Started: c.Now()}
+	var err error
+	ctx, span := startSpan(ctx, "ScheduledRun", AttrJob.String(j.Name))
+	defer func() { endSpan(span, err) }()
+
+	if j.NewTab {
+		var cancel context.CancelFunc
+		ctx, cancel = chromedp.NewContext(ctx)
+		defer cancel()
+	}
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+	err = s.runTasks(ctx, j.Tasks)
+
+	r.Finished = c.Now()
+	r.Status = RunSucceeded
+	if err != nil {
+		r.Status = RunFailed
+		r.Err = err
+	}
+	s.record(r)
+}
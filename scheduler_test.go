@@ -0,0 +1,267 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// startTestScheduler runs s with a FakeClock and returns a function to stop it.
+func startTestScheduler(t *testing.T, s *Scheduler, c *FakeClock) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(WithClock(context.Background(), c))
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	return func() {
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Fatalf("%#v != %#v", err, context.Canceled)
+		}
+	}
+}
+
+func runStatuses(runs []Run) []RunStatus {
+	var statuses []RunStatus
+	for _, r := range runs {
+		statuses = append(statuses, r.Status)
+	}
+	return statuses
+}
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	s := NewScheduler()
+	ran := make(chan struct{}, 10)
+	s.run = func(ctx context.Context, action chromedp.Action) error {
+		err := action.Do(ctx)
+		ran <- struct{}{}
+		return err
+	}
+	count := 0
+	if err := s.Add(&Job{
+		Name:     "count",
+		Schedule: Every(time.Minute),
+		Tasks: chromedp.Tasks{chromedp.ActionFunc(func(context.Context) error {
+			count++
+			if count == 2 {
+				return errors.New("failed")
+			}
+			return nil
+		})},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	stop := startTestScheduler(t, s, c)
+	for i := 0; i < 3; i++ {
+		c.BlockUntil(1)
+		c.Advance(time.Minute)
+		<-ran
+	}
+	stop()
+
+	runs := s.History()
+	// runs may be recorded out of order as they finish concurrently
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Scheduled.Before(runs[j].Scheduled) })
+	if len(runs) != 3 {
+		t.Fatalf("%#v != %#v", len(runs), 3)
+	}
+	for i, r := range runs {
+		if want := start.Add(time.Duration(i+1) * time.Minute); !r.Scheduled.Equal(want) {
+			t.Errorf("%v != %v", r.Scheduled, want)
+		}
+		if r.Job != "count" {
+			t.Errorf("%#v != %#v", r.Job, "count")
+		}
+	}
+	if want := []RunStatus{RunSucceeded, RunFailed, RunSucceeded}; !reflect.DeepEqual(runStatuses(runs), want) {
+		t.Fatalf("%#v != %#v", runStatuses(runs), want)
+	}
+	if runs[1].Err == nil {
+		t.Fatal("error must be recorded")
+	}
+}
+
+func TestSchedulerSkipIfRunning(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler()
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	s.run = func(ctx context.Context, action chromedp.Action) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	if err := s.Add(&Job{Name: "slow", Schedule: Every(time.Minute), SkipIfRunning: true}); err != nil {
+		t.Fatal(err)
+	}
+	stop := startTestScheduler(t, s, c)
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	<-started
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	c.BlockUntil(1)
+	close(release)
+	stop()
+
+	want := []RunStatus{RunSkipped, RunSucceeded}
+	if got := runStatuses(s.History()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%#v != %#v", got, want)
+	}
+}
+
+func TestSchedulerMissed(t *testing.T) {
+	t.Parallel()
+	hourly, err := ParseCron("@hourly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		schedule Schedule
+		missed   MissedRunPolicy
+		advance  time.Duration
+		want     []RunStatus
+	}{
+		"late skipped": {
+			schedule: hourly,
+			missed:   SkipMissed,
+			advance:  90 * time.Minute,
+			want:     []RunStatus{RunMissed},
+		},
+		"late run once": {
+			schedule: hourly,
+			missed:   RunMissedOnce,
+			advance:  150 * time.Minute,
+			want:     []RunStatus{RunMissed, RunSucceeded},
+		},
+		"on time after missed": {
+			schedule: Every(time.Minute),
+			missed:   SkipMissed,
+			advance:  3 * time.Minute,
+			want:     []RunStatus{RunMissed, RunMissed, RunSucceeded},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+			s := NewScheduler()
+			s.run = func(ctx context.Context, action chromedp.Action) error { return nil }
+			if err := s.Add(&Job{Name: "job", Schedule: tt.schedule, Missed: tt.missed}); err != nil {
+				t.Fatal(err)
+			}
+			stop := startTestScheduler(t, s, c)
+			c.BlockUntil(1)
+			c.Advance(tt.advance)
+			c.BlockUntil(1)
+			stop()
+			if got := runStatuses(s.History()); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerHistoryLimit(t *testing.T) {
+	t.Parallel()
+	s := NewScheduler()
+	s.HistoryLimit = 2
+	for i := 0; i < 3; i++ {
+		s.record(Run{Job: "job", Scheduled: time.Unix(int64(i), 0)})
+	}
+	runs := s.History()
+	if len(runs) != 2 {
+		t.Fatalf("%#v != %#v", len(runs), 2)
+	}
+	if runs[0].Scheduled.Unix() != 1 {
+		t.Fatalf("%#v != %#v", runs[0].Scheduled.Unix(), 1)
+	}
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	t.Parallel()
+	s := NewScheduler()
+	if err := s.Add(&Job{Name: "job", Schedule: Every(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	for _, j := range []*Job{
+		{Schedule: Every(time.Minute)},
+		{Name: "nil"},
+		{Name: "job", Schedule: Every(time.Minute)},
+	} {
+		if err := s.Add(j); err == nil {
+			t.Errorf("expected error job=%#v", j.Name)
+		}
+	}
+}
+
+func TestSchedulerTimeout(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler()
+	ran := make(chan struct{}, 10)
+	s.run = func(ctx context.Context, action chromedp.Action) error {
+		defer func() { ran <- struct{}{} }()
+		return action.Do(ctx)
+	}
+	if err := s.Add(&Job{
+		Name:     "slow",
+		Schedule: Every(24 * time.Hour),
+		Timeout:  time.Minute,
+		Tasks: chromedp.Tasks{chromedp.ActionFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	stop := startTestScheduler(t, s, c)
+	c.BlockUntil(1)
+	c.Advance(24 * time.Hour)
+	// the timer of the next run and the timeout of the running run
+	c.BlockUntil(2)
+	c.Advance(time.Minute)
+	<-ran
+	stop()
+
+	runs := s.History()
+	if want := []RunStatus{RunFailed}; !reflect.DeepEqual(runStatuses(runs), want) {
+		t.Fatalf("%#v != %#v", runStatuses(runs), want)
+	}
+	if !errors.Is(runs[0].Err, context.DeadlineExceeded) {
+		t.Fatalf("%#v != %#v", runs[0].Err, context.DeadlineExceeded)
+	}
+}
+
+func TestSchedulerZeroValue(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := &Scheduler{}
+	if err := s.Add(&Job{Name: "zero", Schedule: Every(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	stop := startTestScheduler(t, s, c)
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	// the run has started when the next run is scheduled, and Run waits for it
+	c.BlockUntil(1)
+	stop()
+
+	// the tasks are run by chromedp.Run, which fails without a browser
+	runs := s.History()
+	if want := []RunStatus{RunFailed}; !reflect.DeepEqual(runStatuses(runs), want) {
+		t.Fatalf("%#v != %#v", runStatuses(runs), want)
+	}
+	if !errors.Is(runs[0].Err, chromedp.ErrInvalidContext) {
+		t.Fatalf("%#v != %#v", runs[0].Err, chromedp.ErrInvalidContext)
+	}
+}
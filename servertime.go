@@ -0,0 +1,243 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+)
+
+// ClockOffset is an estimated offset of a server clock to the local clock.
+type ClockOffset struct {
+	// Offset is the server time minus the local time.
+	Offset time.Duration
+	// Error is the maximum error of Offset.
+	Error time.Duration
+}
+
+// ServerClock estimates the clock of a server from samples of its time.
+type ServerClock struct {
+	// Sample returns the server time at some instant during the call.
+	Sample func(ctx context.Context) (time.Time, error)
+	// Resolution is the resolution of the sampled time, which is truncated to it.
+	Resolution time.Duration
+	// Samples is the number of samples to estimate the offset. The default is 8.
+	Samples int
+
+	mu     sync.Mutex
+	offset *ClockOffset
+}
+
+// DateHeaderClock returns a ServerClock which samples the Date header of HEAD requests to urlstr.
+//
+// The requests are sent by fetch in the page, so urlstr must be same-origin
+// or expose the Date header by Access-Control-Expose-Headers.
+// The Date header has a resolution of 1 second, so samples are timed to narrow the offset down to the round trip time.
+//
+// urlstr can be specified by string, string pointer or fmt.Stringer.
+func DateHeaderClock(urlstr interface{}) *ServerClock {
+	return &ServerClock{
+		Sample: func(ctx context.Context) (time.Time, error) {
+			expr := fmt.Sprintf(`fetch(%q, {method: "HEAD", cache: "no-store", credentials: "include"}).then(r => r.headers.get("Date"))`, toString(urlstr))
+			var date string
+			if err := chromedp.Evaluate(expr, &date, awaitPromise).Do(ctx); err != nil {
+				return time.Time{}, err
+			}
+			if date == "" {
+				return time.Time{}, errors.New("no Date header url=" + toString(urlstr))
+			}
+			return http.ParseTime(date)
+		},
+		Resolution: time.Second,
+	}
+}
+
+// JSClock returns a ServerClock which samples the milliseconds since the Unix epoch returned by expression.
+//
+// expression is evaluated in the page and can return a promise, e.g. a fetch of a time endpoint of the server.
+func JSClock(expression string) *ServerClock {
+	return &ServerClock{
+		Sample: func(ctx context.Context) (time.Time, error) {
+			var ms float64
+			if err := chromedp.Evaluate(expression, &ms, awaitPromise).Do(ctx); err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(0, int64(ms*float64(time.Millisecond))), nil
+		},
+		Resolution: time.Millisecond,
+	}
+}
+
+// Offset returns the offset estimated by the last Sync, and false if it has not been synced.
+func (c *ServerClock) Offset() (ClockOffset, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.offset == nil {
+		return ClockOffset{}, false
+	}
+	return *c.offset, true
+}
+
+// Sync is an action that estimates the offset of the server clock.
+//
+// Each sample bounds the offset by its round trip time and the resolution,
+// and the bounds of all samples are intersected.
+func (c *ServerClock) Sync() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		offset, err := c.estimate(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("ServerClock: offset=%s error=%s\n", offset.Offset, offset.Error)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.offset = &offset
+		return nil
+	})
+}
+
+// serverSample is a sample of the server time taken between the local times sent and received.
+type serverSample struct {
+	server, sent, received time.Time
+}
+
+func (c *ServerClock) estimate(ctx context.Context) (ClockOffset, error) {
+	lc := clock(ctx)
+	n := c.Samples
+	if n <= 0 {
+		n = 8
+	}
+	var samples []serverSample
+	var lo, hi time.Duration
+	for i := 0; i < n; i++ {
+		if i > 0 && lo <= hi {
+			// time the sample at the next boundary of the resolution in the estimated server time,
+			// so that the truncated time tells which half of the bounds the offset is in
+			last := samples[len(samples)-1]
+			now := lc.Now()
+			server := now.Add(lo + (hi-lo)/2)
+			wait := server.Truncate(c.Resolution).Add(c.Resolution).Sub(server) - last.received.Sub(last.sent)/2
+			if wait > 0 && wait < c.Resolution {
+				if err := WaitForTime(now.Add(wait)).Do(ctx); err != nil {
+					return ClockOffset{}, err
+				}
+			}
+		}
+		s := serverSample{sent: lc.Now()}
+		var err error
+		if s.server, err = c.Sample(ctx); err != nil {
+			return ClockOffset{}, err
+		}
+		s.received = lc.Now()
+		samples = append(samples, s)
+		slo, shi := s.server.Sub(s.received), s.server.Add(c.Resolution).Sub(s.sent)
+		if i == 0 || slo > lo {
+			lo = slo
+		}
+		if i == 0 || shi < hi {
+			hi = shi
+		}
+	}
+	if lo <= hi {
+		return ClockOffset{Offset: lo + (hi-lo)/2, Error: (hi - lo) / 2}, nil
+	}
+	// the samples are inconsistent, e.g. the server clock has been adjusted,
+	// so use the sample of the shortest round trip
+	log.Printf("ServerClock: inconsistent samples lo=%s hi=%s\n", lo, hi)
+	best := samples[0]
+	for _, s := range samples[1:] {
+		if s.received.Sub(s.sent) < best.received.Sub(best.sent) {
+			best = s
+		}
+	}
+	rtt := best.received.Sub(best.sent)
+	return ClockOffset{
+		Offset: best.server.Add(c.Resolution / 2).Sub(best.sent.Add(rtt / 2)),
+		Error:  (c.Resolution + rtt) / 2,
+	}, nil
+}
+
+// ArmFunc prepares an action before the target time of WaitForServerTime, and returns the action to fire at the target time.
+type ArmFunc func(ctx context.Context) (chromedp.Action, error)
+
+// ArmClick returns an ArmFunc which scrolls the first element matching sel into view and moves the mouse over it,
+// so that firing the click dispatches only the mouse press and release.
+func ArmClick(sel interface{}, opts ...chromedp.QueryOption) ArmFunc {
+	return func(ctx context.Context) (chromedp.Action, error) {
+		var nodes []*cdp.Node
+		if err := chromedp.Nodes(sel, &nodes, append([]chromedp.QueryOption{chromedp.NodeVisible}, opts...)...).Do(ctx); err != nil {
+			return nil, err
+		}
+		if err := dom.ScrollIntoViewIfNeeded().WithNodeID(nodes[0].NodeID).Do(ctx); err != nil {
+			return nil, err
+		}
+		quads, err := dom.GetContentQuads().WithNodeID(nodes[0].NodeID).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(quads) == 0 || len(quads[0]) < 2 || len(quads[0])%2 != 0 {
+			return nil, chromedp.ErrInvalidDimensions
+		}
+		var x, y float64
+		q := quads[0]
+		for i := 0; i < len(q); i += 2 {
+			x += q[i]
+			y += q[i+1]
+		}
+		x /= float64(len(q) / 2)
+		y /= float64(len(q) / 2)
+		if err := chromedp.MouseEvent(input.MouseMoved, x, y).Do(ctx); err != nil {
+			return nil, err
+		}
+		return chromedp.Tasks{
+			chromedp.MouseEvent(input.MousePressed, x, y, chromedp.ButtonLeft, chromedp.ClickCount(1)),
+			chromedp.MouseEvent(input.MouseReleased, x, y, chromedp.ButtonLeft, chromedp.ClickCount(1)),
+		}, nil
+	}
+}
+
+// WaitForServerTime is an action that waits until t in the time of the server clock sc, and fires the action prepared by arm.
+//
+// sc is synced if it has not been synced. Call sc.Sync again to refresh the offset before a long wait.
+// arm is called before waiting, and can be nil to only wait.
+func WaitForServerTime(t time.Time, sc *ServerClock, arm ArmFunc) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		ctx, span := startSpan(ctx, "WaitForServerTime")
+		defer func() { endSpan(span, err) }()
+
+		offset, ok := sc.Offset()
+		if !ok {
+			if err := sc.Sync().Do(ctx); err != nil {
+				return err
+			}
+			offset, _ = sc.Offset()
+		}
+		var fire chromedp.Action
+		if arm != nil {
+			if fire, err = arm(ctx); err != nil {
+				return err
+			}
+		}
+		local := t.Add(-offset.Offset)
+		log.Printf("WaitForServerTime: %s local=%s error=%s\n", t, local, offset.Error)
+		if err := WaitForTime(local).Do(ctx); err != nil {
+			return err
+		}
+		if fire == nil {
+			return nil
+		}
+		if err := fire.Do(ctx); err != nil {
+			return err
+		}
+		log.Printf("WaitForServerTime: fired late=%s\n", clock(ctx).Now().Sub(local))
+		return nil
+	})
+}
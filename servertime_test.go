@@ -0,0 +1,123 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// testServerClock returns a ServerClock of a server whose clock is ahead by offset.
+func testServerClock(offset, resolution, rtt time.Duration) *ServerClock {
+	return &ServerClock{
+		Sample: func(ctx context.Context) (time.Time, error) {
+			time.Sleep(rtt / 2)
+			now := time.Now().Add(offset).Truncate(resolution)
+			time.Sleep(rtt / 2)
+			return now, nil
+		},
+		Resolution: resolution,
+		Samples:    10,
+	}
+}
+
+func TestServerClockSync(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		offset     time.Duration
+		resolution time.Duration
+	}{
+		"ahead":       {offset: 1234 * time.Millisecond, resolution: 100 * time.Millisecond},
+		"behind":      {offset: -5678 * time.Millisecond, resolution: 100 * time.Millisecond},
+		"millisecond": {offset: 42 * time.Millisecond, resolution: time.Millisecond},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			sc := testServerClock(tt.offset, tt.resolution, 4*time.Millisecond)
+			if _, ok := sc.Offset(); ok {
+				t.Fatal("offset must not be estimated before Sync")
+			}
+			if err := sc.Sync().Do(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := sc.Offset()
+			if !ok {
+				t.Fatal("offset must be estimated after Sync")
+			}
+			if diff := got.Offset - tt.offset; diff > got.Error || -diff > got.Error {
+				t.Fatalf("offset=%s error=%s want=%s", got.Offset, got.Error, tt.offset)
+			}
+			if got.Error > 50*time.Millisecond {
+				t.Fatalf("too large error=%s", got.Error)
+			}
+		})
+	}
+}
+
+func TestServerClockSyncError(t *testing.T) {
+	t.Parallel()
+	want := errors.New("sample error")
+	sc := &ServerClock{
+		Sample:     func(context.Context) (time.Time, error) { return time.Time{}, want },
+		Resolution: time.Second,
+	}
+	if err := sc.Sync().Do(context.Background()); err != want {
+		t.Fatalf("%#v != %#v", err, want)
+	}
+	if _, ok := sc.Offset(); ok {
+		t.Fatal("offset must not be estimated on error")
+	}
+}
+
+func TestServerClockInconsistent(t *testing.T) {
+	t.Parallel()
+	// the server clock jumps by 10 seconds after the first sample
+	jump := time.Duration(0)
+	sc := &ServerClock{
+		Sample: func(context.Context) (time.Time, error) {
+			now := time.Now().Add(jump).Truncate(time.Millisecond)
+			jump = 10 * time.Second
+			return now, nil
+		},
+		Resolution: time.Millisecond,
+		Samples:    3,
+	}
+	if err := sc.Sync().Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := sc.Offset()
+	if got.Error <= 0 || got.Error > 100*time.Millisecond {
+		t.Fatalf("unexpected error=%s", got.Error)
+	}
+}
+
+func TestWaitForServerTime(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+
+	u := testStartServer(t) + "/release"
+	sc := DateHeaderClock(u)
+	sc.Samples = 12
+	target := time.Now().Add(3 * time.Second).Truncate(time.Second)
+	var clickedAt float64
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(u),
+		sc.Sync(),
+		WaitForServerTime(target, sc, ArmClick("#buy", chromedp.ByID)),
+		chromedp.Evaluate(`window.clickedAt`, &clickedAt),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	offset, _ := sc.Offset()
+	got := time.Unix(0, int64(clickedAt)*int64(time.Millisecond))
+	// the server and the browser share the local clock
+	if diff := got.Sub(target); diff < -offset.Error || diff > offset.Error+100*time.Millisecond {
+		t.Fatalf("clicked at %s target=%s error=%s", got, target, offset.Error)
+	}
+}
@@ -0,0 +1,369 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/domstorage"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// StorageStateVersion is the version of the storage state format written by SaveStorageState.
+const StorageStateVersion = 1
+
+// storageStateMarker is the sessionStorage key which marks the origin as restored in the tab.
+const storageStateMarker = "__chromedp_helper_storage_state__"
+
+// StorageState is a snapshot of the browser storage.
+type StorageState struct {
+	Version int               `json:"version"`
+	Cookies []*network.Cookie `json:"cookies"`
+	Origins []*OriginStorage  `json:"origins"`
+}
+
+// OriginStorage is the storage of an origin.
+type OriginStorage struct {
+	Origin         string               `json:"origin"`
+	LocalStorage   map[string]string    `json:"localStorage,omitempty"`
+	SessionStorage map[string]string    `json:"sessionStorage,omitempty"`
+	IndexedDB      []*IndexedDBDatabase `json:"indexedDB,omitempty"`
+}
+
+// IndexedDBDatabase is an IndexedDB database.
+type IndexedDBDatabase struct {
+	Name    string                  `json:"name"`
+	Version int64                   `json:"version"`
+	Stores  []*IndexedDBObjectStore `json:"stores"`
+}
+
+// IndexedDBObjectStore is an object store of IndexedDB database.
+//
+// KeyPath is null for stores using out-of-line keys.
+type IndexedDBObjectStore struct {
+	Name          string             `json:"name"`
+	KeyPath       json.RawMessage    `json:"keyPath"`
+	AutoIncrement bool               `json:"autoIncrement"`
+	Records       []*IndexedDBRecord `json:"records"`
+}
+
+// IndexedDBRecord is a record of IndexedDB object store.
+type IndexedDBRecord struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Origin returns the storage of origin, or nil if s has no storage of origin.
+func (s *StorageState) Origin(origin string) *OriginStorage {
+	for _, o := range s.Origins {
+		if o.Origin == origin {
+			return o
+		}
+	}
+	return nil
+}
+
+// LoadStorageState loads the storage state from file.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func LoadStorageState(filename interface{}) (*StorageState, error) {
+	f, err := os.Open(toString(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readStorageState(f)
+}
+
+// CaptureStorageState is an action that takes a snapshot of the browser storage into state.
+//
+// localStorage and sessionStorage are captured for the origins of all frames in the page and origins.
+// IndexedDB is captured only for the origin of the main frame if indexedDB is true.
+// IndexedDB values which cannot be represented as JSON are not preserved.
+//
+// origins can be specified by string, string pointer or fmt.Stringer.
+func CaptureStorageState(state *StorageState, indexedDB bool, origins ...interface{}) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		cookies, err := network.GetAllCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		var names []string
+		seen := make(map[string]bool)
+		add := func(origin string) {
+			if origin == "" || seen[origin] {
+				return
+			}
+			seen[origin] = true
+			names = append(names, origin)
+		}
+		walkFrameTree(tree, func(f *cdp.Frame) {
+			add(storageOrigin(f.SecurityOrigin))
+		})
+		for _, o := range origins {
+			add(storageOrigin(toString(o)))
+		}
+
+		if err := domstorage.Enable().Do(ctx); err != nil {
+			return err
+		}
+		res := &StorageState{Version: StorageStateVersion, Cookies: cookies, Origins: make([]*OriginStorage, 0, len(names))}
+		for _, name := range names {
+			o := &OriginStorage{Origin: name}
+			if o.LocalStorage, err = getDOMStorage(ctx, name, true); err != nil {
+				return fmt.Errorf("could not get localStorage of %s: %w", name, err)
+			}
+			if o.SessionStorage, err = getDOMStorage(ctx, name, false); err != nil {
+				return fmt.Errorf("could not get sessionStorage of %s: %w", name, err)
+			}
+			if indexedDB && name == storageOrigin(tree.Frame.SecurityOrigin) {
+				if err := chromedp.Evaluate(captureIndexedDBScript, &o.IndexedDB, awaitPromise).Do(ctx); err != nil {
+					return fmt.Errorf("could not get IndexedDB of %s: %w", name, err)
+				}
+			}
+			if len(o.LocalStorage) == 0 && len(o.SessionStorage) == 0 && len(o.IndexedDB) == 0 {
+				continue
+			}
+			res.Origins = append(res.Origins, o)
+		}
+		*state = *res
+		return nil
+	})
+}
+
+// ApplyStorageState is an action that restores the browser storage from state.
+//
+// Storage of origins is restored when a document of the origin is loaded in the tab for the first time,
+// before any script of the page runs, so it works for origins which are not visited yet.
+// Run it before navigation. IndexedDB is restored asynchronously.
+func ApplyStorageState(state *StorageState) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := checkStorageStateVersion(state.Version); err != nil {
+			return err
+		}
+		store := &MemoryCookieStore{}
+		if err := store.Save(ctx, state.Cookies); err != nil {
+			return err
+		}
+		if err := RestoreCookiesFrom(store).Do(ctx); err != nil {
+			return err
+		}
+		if len(state.Origins) == 0 {
+			return nil
+		}
+		script, err := restoreStorageScript(state.Origins)
+		if err != nil {
+			return err
+		}
+		_, err = page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+		return err
+	})
+}
+
+// SaveStorageState is an action that saves a snapshot of the browser storage to file.
+//
+// See CaptureStorageState for the captured storage.
+// The file is written atomically with mode 0600.
+//
+// filename and origins can be specified by string, string pointer or fmt.Stringer.
+func SaveStorageState(filename interface{}, indexedDB bool, origins ...interface{}) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		name := toString(filename)
+		ctx, span := startSpan(ctx, "SaveStorageState", AttrFilename.String(name))
+		defer func() { endSpan(span, err) }()
+
+		var state StorageState
+		if err := CaptureStorageState(&state, indexedDB, origins...).Do(ctx); err != nil {
+			return err
+		}
+		log.Printf("SaveStorageState: cookie(s)=%d origin(s)=%d\n", len(state.Cookies), len(state.Origins))
+		span.SetAttributes(AttrCookies.Int(len(state.Cookies)))
+		return writeFileAtomic(name, 0600, func(w io.Writer) error {
+			return writeStorageState(w, &state)
+		})
+	})
+}
+
+// RestoreStorageState is an action that restores the browser storage from file saved by SaveStorageState.
+//
+// See ApplyStorageState for the restoration.
+//
+// filename can be specified by string, string pointer or fmt.Stringer.
+func RestoreStorageState(filename interface{}) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) (err error) {
+		name := toString(filename)
+		ctx, span := startSpan(ctx, "RestoreStorageState", AttrFilename.String(name))
+		defer func() { endSpan(span, err) }()
+
+		state, err := LoadStorageState(name)
+		if err != nil {
+			return err
+		}
+		log.Printf("RestoreStorageState: cookie(s)=%d origin(s)=%d\n", len(state.Cookies), len(state.Origins))
+		span.SetAttributes(AttrCookies.Int(len(state.Cookies)))
+		return ApplyStorageState(state).Do(ctx)
+	})
+}
+
+func readStorageState(r io.Reader) (*StorageState, error) {
+	var state StorageState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, err
+	}
+	if err := checkStorageStateVersion(state.Version); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func writeStorageState(w io.Writer, state *StorageState) error {
+	return json.NewEncoder(w).Encode(state)
+}
+
+func checkStorageStateVersion(v int) error {
+	if v < 1 || v > StorageStateVersion {
+		return fmt.Errorf("unsupported storage state version %d", v)
+	}
+	return nil
+}
+
+// storageOrigin returns the origin of urlstr, or "" if it has no storage.
+func storageOrigin(urlstr string) string {
+	u, err := url.Parse(urlstr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func walkFrameTree(tree *page.FrameTree, f func(*cdp.Frame)) {
+	f(tree.Frame)
+	for _, child := range tree.ChildFrames {
+		walkFrameTree(child, f)
+	}
+}
+
+func getDOMStorage(ctx context.Context, origin string, local bool) (map[string]string, error) {
+	items, err := domstorage.GetDOMStorageItems(&domstorage.StorageID{SecurityOrigin: origin, IsLocalStorage: local}).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(items))
+	for _, item := range items {
+		if len(item) != 2 || item[0] == storageStateMarker {
+			continue
+		}
+		res[item[0]] = item[1]
+	}
+	return res, nil
+}
+
+func awaitPromise(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+	return p.WithAwaitPromise(true)
+}
+
+func restoreStorageScript(origins []*OriginStorage) (string, error) {
+	m := make(map[string]*OriginStorage, len(origins))
+	for _, o := range origins {
+		m[o.Origin] = o
+	}
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(restoreStorageScriptFormat, storageStateMarker, buf), nil
+}
+
+const captureIndexedDBScript = `(async () => {
+	if (!indexedDB.databases) {
+		return [];
+	}
+	const wait = (req) => new Promise((resolve, reject) => {
+		req.onsuccess = () => resolve(req.result);
+		req.onerror = () => reject(req.error);
+	});
+	const res = [];
+	for (const info of await indexedDB.databases()) {
+		const db = await wait(indexedDB.open(info.name));
+		const stores = [];
+		for (const name of Array.from(db.objectStoreNames)) {
+			const store = db.transaction(name, 'readonly').objectStore(name);
+			const [keys, values] = await Promise.all([wait(store.getAllKeys()), wait(store.getAll())]);
+			stores.push({
+				name: name,
+				keyPath: store.keyPath,
+				autoIncrement: store.autoIncrement,
+				records: values.map((value, i) => ({key: keys[i], value: value})),
+			});
+		}
+		res.push({name: db.name, version: db.version, stores: stores});
+		db.close();
+	}
+	return res;
+})()`
+
+const restoreStorageScriptFormat = `(function(marker, origins) {
+	var o = origins[location.origin];
+	if (!o) {
+		return;
+	}
+	try {
+		if (sessionStorage.getItem(marker)) {
+			return;
+		}
+		sessionStorage.setItem(marker, '1');
+		Object.keys(o.localStorage || {}).forEach(function(k) {
+			localStorage.setItem(k, o.localStorage[k]);
+		});
+		Object.keys(o.sessionStorage || {}).forEach(function(k) {
+			sessionStorage.setItem(k, o.sessionStorage[k]);
+		});
+	} catch (e) {
+		return;
+	}
+	(o.indexedDB || []).forEach(function(db) {
+		var req = indexedDB.open(db.name, db.version);
+		req.onupgradeneeded = function() {
+			db.stores.forEach(function(s) {
+				if (!req.result.objectStoreNames.contains(s.name)) {
+					req.result.createObjectStore(s.name, {keyPath: s.keyPath === null ? undefined : s.keyPath, autoIncrement: s.autoIncrement});
+				}
+			});
+		};
+		req.onsuccess = function() {
+			var d = req.result;
+			var names = db.stores.map(function(s) { return s.name; }).filter(function(n) { return d.objectStoreNames.contains(n); });
+			if (names.length === 0) {
+				d.close();
+				return;
+			}
+			var tx = d.transaction(names, 'readwrite');
+			db.stores.forEach(function(s) {
+				if (names.indexOf(s.name) < 0) {
+					return;
+				}
+				var store = tx.objectStore(s.name);
+				s.records.forEach(function(r) {
+					if (store.keyPath === null) {
+						store.put(r.value, r.key);
+					} else {
+						store.put(r.value);
+					}
+				});
+			});
+			tx.oncomplete = function() { d.close(); };
+		};
+	});
+})(%q, %s);`
@@ -0,0 +1,176 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+)
+
+func testStorageState() *StorageState {
+	return &StorageState{
+		Version: StorageStateVersion,
+		Cookies: testCookies(),
+		Origins: []*OriginStorage{
+			{
+				Origin:         "https://example.com",
+				LocalStorage:   map[string]string{"token": "abc"},
+				SessionStorage: map[string]string{"tab": "1"},
+				IndexedDB: []*IndexedDBDatabase{
+					{
+						Name:    "db",
+						Version: 2,
+						Stores: []*IndexedDBObjectStore{
+							{
+								Name:    "items",
+								KeyPath: json.RawMessage(`"id"`),
+								Records: []*IndexedDBRecord{
+									{Key: json.RawMessage(`1`), Value: json.RawMessage(`{"id":1,"name":"one"}`)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestStorageStateFile(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	want := testStorageState()
+	if err := writeStorageState(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readStorageState(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("\nwant: %+v\n got: %+v", want, got)
+	}
+	if o := got.Origin("https://example.com"); o == nil || o.LocalStorage["token"] != "abc" {
+		t.Fatalf("invalid origin storage: %+v", o)
+	}
+	if o := got.Origin("https://example.org"); o != nil {
+		t.Fatalf("unexpected origin storage: %+v", o)
+	}
+}
+
+func TestStorageStateVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: `{"version":1}`},
+		{in: `{"version":0}`, wantErr: true},
+		{in: `{}`, wantErr: true},
+		{in: `{"version":2}`, wantErr: true},
+	}
+	for _, test := range tests {
+		_, err := readStorageState(strings.NewReader(test.in))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error: %v", test.in, err)
+		}
+	}
+	if err := ApplyStorageState(&StorageState{}).Do(context.Background()); err == nil {
+		t.Error("expected error for unversioned state")
+	}
+}
+
+func TestStorageOrigin(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "https://example.com", want: "https://example.com"},
+		{in: "https://example.com/path?q=1#frag", want: "https://example.com"},
+		{in: "http://127.0.0.1:8080/", want: "http://127.0.0.1:8080"},
+		{in: "://", want: ""},
+		{in: "null", want: ""},
+		{in: "about:blank", want: ""},
+		{in: "data:text/html,test", want: ""},
+	}
+	for _, test := range tests {
+		got := storageOrigin(test.in)
+		if got != test.want {
+			t.Errorf("%s: %#v != %#v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestRestoreStorageScript(t *testing.T) {
+	t.Parallel()
+	script, err := restoreStorageScript(testStorageState().Origins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"` + storageStateMarker + `"`,
+		`{"https://example.com":{"origin":"https://example.com","localStorage":{"token":"abc"}`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script does not contain %s:\n%s", want, script)
+		}
+	}
+}
+
+func TestStorageState(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocateSeparate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	spath := filepath.Join(dir, "state.json")
+
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(endpoint + "/cookies"),
+		chromedp.Evaluate(`localStorage.setItem("test-key", "testval"); sessionStorage.setItem("test-session", "1")`, &[]byte{}),
+		SaveStorageState(spath, true),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadStorageState(spath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Cookies) != 2 {
+		t.Fatalf("invalid cookies: %+v", state.Cookies)
+	}
+	o := state.Origin(endpoint)
+	if o == nil || o.LocalStorage["test-key"] != "testval" || o.SessionStorage["test-session"] != "1" {
+		t.Fatalf("invalid origin storage: %+v", o)
+	}
+
+	ctx2, cancel2 := testAllocateSeparate(t)
+	defer cancel2()
+	var local, session string
+	tasks = chromedp.Tasks{
+		RestoreStorageState(spath),
+		chromedp.Navigate(endpoint + "/index.html"),
+		chromedp.Evaluate(`localStorage.getItem("test-key")`, &local),
+		chromedp.Evaluate(`sessionStorage.getItem("test-session")`, &session),
+	}
+	if err := chromedp.Run(ctx2, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if local != "testval" || session != "1" {
+		t.Fatalf("storage is not restored: local=%#v session=%#v", local, session)
+	}
+}
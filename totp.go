@@ -0,0 +1,119 @@
+package helper
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// SecretProvider provides the shared secret of TOTP.
+type SecretProvider interface {
+	Secret(ctx context.Context) ([]byte, error)
+}
+
+// SecretProviderFunc is an adapter to allow the use of ordinary functions as SecretProvider.
+type SecretProviderFunc func(ctx context.Context) ([]byte, error)
+
+// Secret calls f(ctx).
+func (f SecretProviderFunc) Secret(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// Base32Secret returns a SecretProvider of the base32 encoded secret shown by services when 2FA is set up.
+//
+// Spaces, padding and lower case letters are allowed.
+func Base32Secret(secret string) SecretProvider {
+	return SecretProviderFunc(func(ctx context.Context) ([]byte, error) {
+		s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+		s = strings.TrimRight(s, "=")
+		return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	})
+}
+
+// TOTP generates time-based one-time passwords defined in RFC 6238.
+type TOTP struct {
+	Secret SecretProvider
+	// Digits is the number of digits of codes. The default is 6.
+	Digits int
+	// Period is the time step of codes. The default is 30 seconds.
+	Period time.Duration
+	// Hash is the hash function of HMAC. The default is SHA-1.
+	Hash func() hash.Hash
+}
+
+func (t *TOTP) period() int64 {
+	if t.Period < time.Second {
+		return 30
+	}
+	return int64(t.Period / time.Second)
+}
+
+// Code returns the code at time at.
+func (t *TOTP) Code(ctx context.Context, at time.Time) (string, error) {
+	secret, err := t.Secret.Secret(ctx)
+	if err != nil {
+		return "", err
+	}
+	digits := t.Digits
+	if digits <= 0 {
+		digits = 6
+	}
+	h := t.Hash
+	if h == nil {
+		h = sha1.New
+	}
+	return totpCode(h, secret, at.Unix()/t.period(), digits), nil
+}
+
+// expires returns the time when the code at time at expires.
+func (t *TOTP) expires(at time.Time) time.Time {
+	p := t.period()
+	return time.Unix((at.Unix()/p+1)*p, 0)
+}
+
+// totpCode returns the HOTP code of counter defined in RFC 4226.
+func totpCode(h func() hash.Hash, secret []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(h, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod)
+}
+
+// TypeTOTP is an action that types the current code of t into the element matching sel.
+//
+// If the current code expires within margin, it waits for the next time window by WaitForTime.
+func TypeTOTP(sel interface{}, t *TOTP, margin time.Duration, opts ...chromedp.QueryOption) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		now := clock(ctx).Now()
+		if expires := t.expires(now); expires.Sub(now) < margin {
+			log.Printf("TypeTOTP: wait for next window expires=%s\n", expires.Format(time.RFC3339))
+			if err := WaitForTime(expires).Do(ctx); err != nil {
+				return err
+			}
+			now = expires
+		}
+		code, err := t.Code(ctx, now)
+		if err != nil {
+			return err
+		}
+		log.Printf("TypeTOTP: type code sel=%v\n", sel)
+		return chromedp.SendKeys(sel, code, opts...).Do(ctx)
+	})
+}
@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func staticSecret(secret string) SecretProvider {
+	return SecretProviderFunc(func(context.Context) ([]byte, error) {
+		return []byte(secret), nil
+	})
+}
+
+func TestTOTPCode(t *testing.T) {
+	t.Parallel()
+	// test vectors of RFC 6238 Appendix B
+	const (
+		sha1Secret   = "12345678901234567890"
+		sha256Secret = "12345678901234567890123456789012"
+		sha512Secret = "1234567890123456789012345678901234567890123456789012345678901234"
+	)
+	tests := []struct {
+		name   string
+		hash   func() hash.Hash
+		secret string
+		at     int64
+		want   string
+	}{
+		{name: "sha1 at 59", hash: sha1.New, secret: sha1Secret, at: 59, want: "94287082"},
+		{name: "sha1 at 1111111109", hash: sha1.New, secret: sha1Secret, at: 1111111109, want: "07081804"},
+		{name: "sha1 at 1111111111", hash: sha1.New, secret: sha1Secret, at: 1111111111, want: "14050471"},
+		{name: "sha1 at 1234567890", hash: sha1.New, secret: sha1Secret, at: 1234567890, want: "89005924"},
+		{name: "sha1 at 2000000000", hash: sha1.New, secret: sha1Secret, at: 2000000000, want: "69279037"},
+		{name: "sha1 at 20000000000", hash: sha1.New, secret: sha1Secret, at: 20000000000, want: "65353130"},
+		{name: "sha256 at 59", hash: sha256.New, secret: sha256Secret, at: 59, want: "46119246"},
+		{name: "sha256 at 1111111109", hash: sha256.New, secret: sha256Secret, at: 1111111109, want: "68084774"},
+		{name: "sha512 at 59", hash: sha512.New, secret: sha512Secret, at: 59, want: "90693936"},
+		{name: "sha512 at 1111111109", hash: sha512.New, secret: sha512Secret, at: 1111111109, want: "25091201"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			totp := &TOTP{Secret: staticSecret(tt.secret), Digits: 8, Hash: tt.hash}
+			got, err := totp.Code(context.Background(), time.Unix(tt.at, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOTPDefaults(t *testing.T) {
+	t.Parallel()
+	// base32 of "12345678901234567890"
+	totp := &TOTP{Secret: Base32Secret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")}
+	got, err := totp.Code(context.Background(), time.Unix(59, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "287082" {
+		t.Fatalf("%#v != %#v", got, "287082")
+	}
+	if got, want := totp.expires(time.Unix(59, 0)), time.Unix(60, 0); !got.Equal(want) {
+		t.Fatalf("%v != %v", got, want)
+	}
+	if got, want := totp.expires(time.Unix(60, 0)), time.Unix(90, 0); !got.Equal(want) {
+		t.Fatalf("%v != %v", got, want)
+	}
+	if _, err := Base32Secret("!!").Secret(context.Background()); err == nil {
+		t.Fatal("expected error for invalid secret")
+	}
+}
+
+func TestTypeTOTP(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+
+	totp := &TOTP{Secret: staticSecret("12345678901234567890"), Period: 2 * time.Second}
+	var got string
+	var before time.Time
+	tasks := chromedp.Tasks{
+		chromedp.Navigate(`data:text/html,<input id="code">`),
+		chromedp.ActionFunc(func(context.Context) error {
+			before = time.Now()
+			return nil
+		}),
+		TypeTOTP("#code", totp, 2*time.Second, chromedp.ByID),
+		chromedp.Value("#code", &got, chromedp.ByID),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+	// the code is of the window after before because the margin is the period
+	want, err := totp.Code(context.Background(), totp.expires(before))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("%#v != %#v", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package helper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = modulePath
+
+// Span attribute keys recorded by the helper actions.
+const (
+	AttrURL       = attribute.Key("helper.url")
+	AttrFilename  = attribute.Key("helper.filename")
+	AttrStatus    = attribute.Key("helper.status")
+	AttrReloads   = attribute.Key("helper.reloads")
+	AttrBytes     = attribute.Key("helper.bytes")
+	AttrCookies   = attribute.Key("helper.cookies")
+	AttrRequestID = attribute.Key("helper.request_id")
+	AttrMethod    = attribute.Key("helper.method")
+	AttrJob       = attribute.Key("helper.job")
+)
+
+type tracerProviderKey struct{}
+
+// noSpan is a non-recording span used when no tracer provider is configured.
+var noSpan = trace.SpanFromContext(context.Background())
+
+// WithTracerProvider returns a copy of ctx in which the helper actions create spans with tp.
+//
+// Tracing is disabled when the context carries no tracer provider.
+func WithTracerProvider(ctx context.Context, tp trace.TracerProvider) context.Context {
+	return context.WithValue(ctx, tracerProviderKey{}, tp)
+}
+
+// startSpan starts a span named name if a tracer provider is configured in ctx.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tp, ok := ctx.Value(tracerProviderKey{}).(trace.TracerProvider)
+	if !ok || tp == nil {
+		return ctx, noSpan
+	}
+	return tp.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if !span.IsRecording() {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// requestSpans tracks child spans of network requests observed by an action.
+type requestSpans struct {
+	ctx    context.Context
+	parent trace.Span
+	mu     sync.Mutex
+	spans  map[network.RequestID]trace.Span
+}
+
+func newRequestSpans(ctx context.Context, parent trace.Span) *requestSpans {
+	return &requestSpans{
+		ctx:    ctx,
+		parent: parent,
+		spans:  make(map[network.RequestID]trace.Span),
+	}
+}
+
+func (r *requestSpans) start(id network.RequestID, method, urlstr string) {
+	if !r.parent.IsRecording() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// a redirect reuses the request id of the original request
+	if span, ok := r.spans[id]; ok {
+		span.End()
+	}
+	_, span := startSpan(r.ctx, "Request",
+		AttrRequestID.String(string(id)),
+		AttrMethod.String(method),
+		AttrURL.String(urlstr),
+	)
+	r.spans[id] = span
+}
+
+func (r *requestSpans) status(id network.RequestID, status int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if span, ok := r.spans[id]; ok {
+		span.SetAttributes(AttrStatus.Int64(status))
+	}
+}
+
+func (r *requestSpans) end(id network.RequestID, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if span, ok := r.spans[id]; ok {
+		endSpan(span, err)
+		delete(r.spans, id)
+	}
+}
+
+// endAll ends the spans of requests which have not finished yet.
+func (r *requestSpans) endAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, span := range r.spans {
+		span.End()
+		delete(r.spans, id)
+	}
+}
@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func testTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exp := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)), exp
+}
+
+func TestStartSpan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no tracer provider", func(t *testing.T) {
+		ctx := context.Background()
+		got, span := startSpan(ctx, "test")
+		if got != ctx {
+			t.Fatal("expected context not to be modified")
+		}
+		if span.IsRecording() {
+			t.Fatal("expected span not to be recording")
+		}
+		endSpan(span, errors.New("error"))
+	})
+
+	t.Run("with tracer provider", func(t *testing.T) {
+		tp, exp := testTracerProvider()
+		ctx := WithTracerProvider(context.Background(), tp)
+		_, span := startSpan(ctx, "test", AttrURL.String("https://example.com"))
+		endSpan(span, errors.New("error"))
+
+		spans := exp.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("expected 1 span, got %d", len(spans))
+		}
+		if spans[0].Name != "test" {
+			t.Fatalf("expected span name to be %q, got %q", "test", spans[0].Name)
+		}
+		if spans[0].Status.Code != codes.Error {
+			t.Fatalf("expected span status to be %v, got %v", codes.Error, spans[0].Status.Code)
+		}
+		if len(spans[0].Attributes) != 1 || spans[0].Attributes[0] != AttrURL.String("https://example.com") {
+			t.Fatalf("unexpected attributes: %v", spans[0].Attributes)
+		}
+	})
+}
+
+func TestRequestSpans(t *testing.T) {
+	t.Parallel()
+	tp, exp := testTracerProvider()
+	ctx, parent := startSpan(WithTracerProvider(context.Background(), tp), "parent")
+
+	r := newRequestSpans(ctx, parent)
+	r.start("1", "GET", "https://example.com/")
+	r.status("1", 200)
+	r.end("1", nil)
+	r.start("2", "GET", "https://example.com/image.png")
+	r.end("2", errors.New("net::ERR_FAILED"))
+	r.start("3", "GET", "https://example.com/pending")
+	r.endAll()
+	endSpan(parent, nil)
+
+	spans := exp.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	parentID := spans[3].SpanContext.SpanID()
+	for _, s := range spans[:3] {
+		if s.Parent.SpanID() != parentID {
+			t.Fatalf("expected span %q to be a child of parent", s.Name)
+		}
+	}
+	if spans[1].Status.Code != codes.Error {
+		t.Fatalf("expected failed request span to have error status, got %v", spans[1].Status.Code)
+	}
+}
+
+func TestNavigateTracing(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testAllocate(t)
+	defer cancel()
+	endpoint := testStartServer(t)
+
+	tp, exp := testTracerProvider()
+	ctx = WithTracerProvider(ctx, tp)
+	tasks := chromedp.Tasks{
+		network.Enable(),
+		EnableLifeCycleEvents(),
+		Navigate(endpoint+"/navigate.html", 5*time.Second),
+	}
+	if err := chromedp.Run(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]int)
+	for _, s := range exp.GetSpans() {
+		names[s.Name]++
+	}
+	for _, name := range []string{"Navigate", "WaitResponse", "Request"} {
+		if names[name] == 0 {
+			t.Fatalf("expected span %q to be recorded, got %v", name, names)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// URLBuilder builds a URL from an endpoint and a path template every time it is used.
+//
+// The values of placeholders, query parameters and the fragment are resolved when the URL is built,
+// so they can be set by actions which run before the URL is used.
+type URLBuilder struct {
+	endpoint string
+	path     string
+	params   map[string]interface{}
+	query    []urlQuery
+	fragment interface{}
+}
+
+type urlQuery struct {
+	key string
+	val interface{}
+}
+
+// NewURL returns a URLBuilder of path joined to the path of endpoint.
+//
+// path can contain named placeholders such as "/users/{id}", which are replaced by the values set by Param.
+// For example, "/users/{id}" joined to "https://example.com/api" is "https://example.com/api/users/{id}".
+func NewURL(endpoint, path string) *URLBuilder {
+	return &URLBuilder{endpoint: endpoint, path: path, params: map[string]interface{}{}}
+}
+
+// Param sets the value of the placeholder {name} in the path. The value is path escaped, so it is a single segment.
+//
+// val can be specified by string, string pointer or fmt.Stringer.
+func (b *URLBuilder) Param(name string, val interface{}) *URLBuilder {
+	b.params[name] = val
+	return b
+}
+
+// Query adds the query parameter key. The query parameters of the endpoint are kept.
+//
+// val can be specified by string, string pointer or fmt.Stringer.
+func (b *URLBuilder) Query(key string, val interface{}) *URLBuilder {
+	b.query = append(b.query, urlQuery{key: key, val: val})
+	return b
+}
+
+// Fragment sets the fragment.
+//
+// val can be specified by string, string pointer or fmt.Stringer.
+func (b *URLBuilder) Fragment(val interface{}) *URLBuilder {
+	b.fragment = val
+	return b
+}
+
+// Build returns the URL with the current values.
+func (b *URLBuilder) Build() (string, error) {
+	u, err := url.Parse(b.endpoint)
+	if err != nil {
+		return "", err
+	}
+	path, err := b.expand()
+	if err != nil {
+		return "", err
+	}
+	if path != "" {
+		raw := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + strings.TrimPrefix(path, "/")
+		if u.Path, err = url.PathUnescape(raw); err != nil {
+			return "", err
+		}
+		u.RawPath = raw
+	}
+	if len(b.query) > 0 {
+		q := u.Query()
+		for _, p := range b.query {
+			q.Add(p.key, toString(p.val))
+		}
+		u.RawQuery = q.Encode()
+	}
+	if b.fragment != nil {
+		u.Fragment = toString(b.fragment)
+	}
+	return u.String(), nil
+}
+
+// expand returns the escaped path whose placeholders are replaced by the values.
+func (b *URLBuilder) expand() (string, error) {
+	var sb strings.Builder
+	rest := b.path
+	for {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			sb.WriteString(rest)
+			return sb.String(), nil
+		}
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return "", errors.New("unclosed placeholder path=" + b.path)
+		}
+		name := rest[i+1 : i+j]
+		val, ok := b.params[name]
+		if !ok {
+			return "", fmt.Errorf("no value for placeholder %s path=%s", name, b.path)
+		}
+		sb.WriteString(rest[:i])
+		sb.WriteString(url.PathEscape(toString(val)))
+		rest = rest[i+j+1:]
+	}
+}
+
+// String implements fmt.Stringer. It returns an empty string if the URL cannot be built.
+func (b *URLBuilder) String() string {
+	s, err := b.Build()
+	if err != nil {
+		log.Printf("URL: error=%v\n", err)
+		return ""
+	}
+	return s
+}
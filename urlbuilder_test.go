@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestURLBuilder(t *testing.T) {
+	t.Parallel()
+	id := "1"
+	tests := []struct {
+		name    string
+		builder func() *URLBuilder
+		want    string
+	}{
+		{
+			name:    "no placeholders",
+			builder: func() *URLBuilder { return NewURL("https://example.com", "/path/to/resource") },
+			want:    "https://example.com/path/to/resource",
+		},
+		{
+			name:    "empty path",
+			builder: func() *URLBuilder { return NewURL("https://example.com/api/", "") },
+			want:    "https://example.com/api/",
+		},
+		{
+			name:    "join endpoint path",
+			builder: func() *URLBuilder { return NewURL("https://example.com/api/", "/users/") },
+			want:    "https://example.com/api/users/",
+		},
+		{
+			name:    "join relative path",
+			builder: func() *URLBuilder { return NewURL("https://example.com/api", "users") },
+			want:    "https://example.com/api/users",
+		},
+		{
+			name: "placeholders",
+			builder: func() *URLBuilder {
+				return NewURL("https://example.com", "/users/{id}/posts/{post}").Param("id", &id).Param("post", "2")
+			},
+			want: "https://example.com/users/1/posts/2",
+		},
+		{
+			name: "escaped values",
+			builder: func() *URLBuilder {
+				return NewURL("https://example.com/a%2Fb", "/files/{name}").Param("name", "dir/file name?.txt")
+			},
+			want: "https://example.com/a%2Fb/files/dir%2Ffile%20name%3F.txt",
+		},
+		{
+			name: "query and fragment",
+			builder: func() *URLBuilder {
+				return NewURL("https://example.com/search?lang=ja", "").Query("q", "a&b").Query("id", &id).Fragment("top")
+			},
+			want: "https://example.com/search?id=1&lang=ja&q=a%26b#top",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.builder().Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("%#v != %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLBuilderLazy(t *testing.T) {
+	t.Parallel()
+	var id, page string
+	u := NewURL("https://example.com", "/users/{id}").Param("id", &id).Query("page", &page).Fragment(&page)
+	id, page = "1", "2"
+	if got, want := u.String(), "https://example.com/users/1?page=2#2"; got != want {
+		t.Fatalf("%#v != %#v", got, want)
+	}
+	id = "3"
+	if got, want := u.String(), "https://example.com/users/3?page=2#2"; got != want {
+		t.Fatalf("%#v != %#v", got, want)
+	}
+}
+
+func TestURLBuilderError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		builder *URLBuilder
+	}{
+		{name: "invalid endpoint", builder: NewURL("://example.com", "/")},
+		{name: "missing value", builder: NewURL("https://example.com", "/users/{id}")},
+		{name: "unclosed placeholder", builder: NewURL("https://example.com", "/users/{id").Param("id", "1")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := tt.builder.Build(); err == nil {
+				t.Fatal("expected error")
+			}
+			if got := tt.builder.String(); got != "" {
+				t.Fatalf("%#v != %#v", got, "")
+			}
+		})
+	}
+}
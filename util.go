@@ -2,10 +2,16 @@ package helper
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
 )
 
 // URL returns url string from endpoint and path.
+//
+// It panics if endpoint is invalid. Use NewURL for placeholders, query parameters and errors.
 func URL(endpoint, path string, val ...*string) fmt.Stringer {
 	u, err := url.Parse(endpoint)
 	if err != nil {
@@ -55,3 +61,31 @@ func toString(val interface{}) string {
 		return ""
 	}
 }
+
+// writeFileAtomic writes the file by write via a temporary file,
+// so that the file is not truncated even if writing is interrupted.
+func writeFileAtomic(filename string, perm os.FileMode, write func(io.Writer) error) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
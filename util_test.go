@@ -1,6 +1,12 @@
 package helper
 
 import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"testing"
 )
@@ -130,3 +136,58 @@ func TestToString(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "chromedp-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fpath := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(fpath, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// failed writing must keep the original file
+	werr := errors.New("write error")
+	err = writeFileAtomic(fpath, 0600, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return werr
+	})
+	if err != werr {
+		t.Fatalf("%#v != %#v", err, werr)
+	}
+	got, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "original" {
+		t.Fatalf("expected original content, got %q", got)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(files))
+	for _, fi := range files {
+		names = append(names, fi.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"file"}) {
+		t.Fatalf("expected temporary file to be removed, got %v", names)
+	}
+
+	if err := writeFileAtomic(fpath, 0600, func(w io.Writer) error {
+		_, err := io.WriteString(w, "replaced")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "replaced" {
+		t.Fatalf("expected replaced content, got %q", got)
+	}
+}